@@ -0,0 +1,197 @@
+// Package baseline records the violations of a set of Makefiles so later
+// runs only report new ones. Violations are identified by a fingerprint of
+// their rule, their message and the content of the offending lines rather
+// than by their line number, so edits elsewhere in a file don't invalidate
+// the baseline.
+package baseline
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/checkmake/checkmake/rules"
+)
+
+// Version is the version of the baseline file format
+const Version = 1
+
+// reCounts matches the parenthesized parts of violation messages holding
+// line numbers or counts like "(lines 3 and 7)", whose numbers are left out
+// of fingerprints. Numbers in quoted names like "test1" are kept.
+var reCounts = regexp.MustCompile(`\([^()"]*\d[^()"]*\)`)
+
+// reNumbers matches the numbers within the parts matched by reCounts
+var reNumbers = regexp.MustCompile(`\d+`)
+
+// Baseline is the list of accepted violations
+type Baseline struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is a single accepted violation
+type Entry struct {
+	Rule        string `json:"rule"`
+	FileName    string `json:"file_name"`
+	Fingerprint string `json:"fingerprint"`
+	// LineNumber and Violation make the baseline readable, they aren't used
+	// to match violations
+	LineNumber int    `json:"line_number"`
+	Violation  string `json:"violation"`
+}
+
+// key identifies the violations an entry matches
+func (e Entry) key() string {
+	return e.FileName + "\x00" + e.Fingerprint
+}
+
+// Sources provides the content of the checked files for fingerprinting.
+// Files which weren't added are read from disk when needed.
+type Sources struct {
+	files map[string][]string
+}
+
+// NewSources returns an empty Sources struct
+func NewSources() *Sources {
+	return &Sources{files: map[string][]string{}}
+}
+
+// Add registers the content of a file, e.g. of a Makefile read from stdin
+func (s *Sources) Add(fileName, content string) {
+	s.files[normalize(fileName)] = strings.Split(content, "\n")
+}
+
+// lines returns the lines of the file, which are empty if it can't be read
+func (s *Sources) lines(fileName string) []string {
+	if lines, ok := s.files[fileName]; ok {
+		return lines
+	}
+	data, err := os.ReadFile(fileName)
+	var lines []string
+	if err == nil {
+		lines = strings.Split(string(data), "\n")
+	}
+	s.files[fileName] = lines
+	return lines
+}
+
+// New returns a baseline accepting all of the violations
+func New(violations rules.RuleViolationList, sources *Sources) *Baseline {
+	ret := &Baseline{Version: Version, Entries: []Entry{}}
+	for _, violation := range violations {
+		ret.Entries = append(ret.Entries, newEntry(violation, sources))
+	}
+	slices.SortStableFunc(ret.Entries, func(a, b Entry) int {
+		return cmp.Or(
+			cmp.Compare(a.FileName, b.FileName),
+			cmp.Compare(a.LineNumber, b.LineNumber),
+			cmp.Compare(a.Rule, b.Rule),
+			cmp.Compare(a.Violation, b.Violation),
+		)
+	})
+	return ret
+}
+
+// Load reads a baseline from a file written by Save
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read baseline: %w", err)
+	}
+	ret := &Baseline{}
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, fmt.Errorf("unable to parse baseline %q: %w", path, err)
+	}
+	if ret.Version != Version {
+		return nil, fmt.Errorf("unsupported baseline version %d in %q (supported: %d)", ret.Version, path, Version)
+	}
+	return ret, nil
+}
+
+// Save writes the baseline to a file
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Filter returns the violations which aren't in the baseline, along with
+// the stale entries of the baseline, which didn't match any violation as
+// they were fixed in the meantime. Only entries of the checked files can
+// be stale. An entry matches a single violation, so additional identical
+// violations are reported as new ones.
+func (b *Baseline) Filter(violations rules.RuleViolationList, sources *Sources, checked []string) (ret rules.RuleViolationList, stale []Entry) {
+	remaining := map[string]int{}
+	for _, entry := range b.Entries {
+		remaining[entry.key()]++
+	}
+
+	for _, violation := range violations {
+		key := newEntry(violation, sources).key()
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		ret = append(ret, violation)
+	}
+
+	isChecked := map[string]bool{}
+	for _, fileName := range checked {
+		isChecked[normalize(fileName)] = true
+	}
+	for _, entry := range b.Entries {
+		if remaining[entry.key()] > 0 && isChecked[entry.FileName] {
+			remaining[entry.key()]--
+			stale = append(stale, entry)
+		}
+	}
+	return
+}
+
+// newEntry returns the baseline entry for the violation
+func newEntry(violation rules.RuleViolation, sources *Sources) Entry {
+	fileName := normalize(violation.FileName)
+	return Entry{
+		Rule:        violation.Rule,
+		FileName:    fileName,
+		Fingerprint: fingerprint(violation, sources.lines(fileName)),
+		LineNumber:  violation.LineNumber,
+		Violation:   violation.Violation,
+	}
+}
+
+// fingerprint hashes the rule and message of the violation along with the
+// offending lines of the file, ignoring their indentation
+func fingerprint(violation rules.RuleViolation, lines []string) string {
+	h := sha256.New()
+	message := reCounts.ReplaceAllStringFunc(violation.Violation, func(counts string) string {
+		return reNumbers.ReplaceAllString(counts, "#")
+	})
+	fmt.Fprintf(h, "%s\x00%s\x00", violation.Rule, message)
+
+	first := violation.LineNumber
+	if violation.Range.Start.Line > 0 {
+		first = violation.Range.Start.Line
+	}
+	last := max(first, violation.EndLineNumber())
+	for line := max(first, 1); line <= last && line <= len(lines); line++ {
+		fmt.Fprintf(h, "%s\n", strings.TrimSpace(lines[line-1]))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// normalize cleans up a file name so that different spellings of the same
+// relative path match
+func normalize(fileName string) string {
+	return filepath.ToSlash(filepath.Clean(fileName))
+}
@@ -0,0 +1,146 @@
+package baseline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func violation(rule, message string, line int) rules.RuleViolation {
+	return rules.RuleViolation{
+		Rule:       rule,
+		Violation:  message,
+		FileName:   "./Makefile",
+		LineNumber: line,
+		Range: parser.Range{
+			Start: parser.Position{Line: line, Column: 1},
+			End:   parser.Position{Line: line, Column: 5},
+		},
+	}
+}
+
+func TestBaseline_IgnoresMovedViolations(t *testing.T) {
+	before := NewSources()
+	before.Add("Makefile", "all:\n\nclean:\n")
+	b := New(rules.RuleViolationList{
+		violation("phonydeclared", `Target "all" should be declared PHONY.`, 1),
+		violation("phonydeclared", `Target "clean" should be declared PHONY.`, 3),
+	}, before)
+	require.Len(t, b.Entries, 2)
+	assert.Equal(t, "Makefile", b.Entries[0].FileName)
+
+	// a new line on top moves both rules down, a new rule adds a violation
+	after := NewSources()
+	after.Add("Makefile", "# header\nall:\n\n  clean:\ninstall:\n")
+	newViolation := violation("phonydeclared", `Target "install" should be declared PHONY.`, 5)
+	ret, stale := b.Filter(rules.RuleViolationList{
+		violation("phonydeclared", `Target "all" should be declared PHONY.`, 2),
+		violation("phonydeclared", `Target "clean" should be declared PHONY.`, 4),
+		newViolation,
+	}, after, []string{"Makefile"})
+
+	assert.Equal(t, rules.RuleViolationList{newViolation}, ret)
+	assert.Empty(t, stale)
+}
+
+func TestBaseline_ReportsStaleEntries(t *testing.T) {
+	sources := NewSources()
+	sources.Add("Makefile", "all:\nclean:\n")
+	b := New(rules.RuleViolationList{
+		violation("phonydeclared", `Target "all" should be declared PHONY.`, 1),
+		violation("phonydeclared", `Target "clean" should be declared PHONY.`, 2),
+	}, sources)
+
+	ret, stale := b.Filter(rules.RuleViolationList{
+		violation("phonydeclared", `Target "all" should be declared PHONY.`, 1),
+	}, sources, []string{"Makefile"})
+	assert.Empty(t, ret)
+	require.Len(t, stale, 1)
+	assert.Equal(t, 2, stale[0].LineNumber)
+
+	// entries of files which weren't checked aren't stale
+	_, stale = b.Filter(nil, sources, []string{"other.mk"})
+	assert.Empty(t, stale)
+}
+
+func TestBaseline_ChangedContentIsNew(t *testing.T) {
+	before := NewSources()
+	before.Add("Makefile", "NOW = $(shell date)\n")
+	v := violation("timestampexpanded", "Variable NOW possibly contains a timestamp and should be simply expanded.", 1)
+	b := New(rules.RuleViolationList{v}, before)
+
+	after := NewSources()
+	after.Add("Makefile", "NOW = $(shell date +%s)\n")
+	ret, stale := b.Filter(rules.RuleViolationList{v}, after, []string{"Makefile"})
+	assert.Len(t, ret, 1)
+	assert.Len(t, stale, 1)
+}
+
+func TestBaseline_KeepsNumbersInNames(t *testing.T) {
+	sources := NewSources()
+	sources.Add("Makefile", ".PHONY: test1 test2\n")
+	b := New(rules.RuleViolationList{
+		violation("minphony", `Required target "test1" must be declared PHONY.`, 1),
+	}, sources)
+
+	// a violation for another target on the same line is new
+	newViolation := violation("minphony", `Required target "test2" must be declared PHONY.`, 1)
+	ret, stale := b.Filter(rules.RuleViolationList{newViolation}, sources, []string{"Makefile"})
+	assert.Equal(t, rules.RuleViolationList{newViolation}, ret)
+	assert.Len(t, stale, 1)
+}
+
+func TestBaseline_IgnoresChangedCounts(t *testing.T) {
+	before := NewSources()
+	before.Add("Makefile", "all:\nall:\n")
+	b := New(rules.RuleViolationList{
+		violation("uniquetargets", `Target "all" defined multiple times (lines 1 and 2).`, 2),
+	}, before)
+
+	after := NewSources()
+	after.Add("Makefile", "\nall:\nall:\n")
+	ret, stale := b.Filter(rules.RuleViolationList{
+		violation("uniquetargets", `Target "all" defined multiple times (lines 2 and 3).`, 3),
+	}, after, []string{"Makefile"})
+	assert.Empty(t, ret)
+	assert.Empty(t, stale)
+}
+
+func TestBaseline_DuplicatesAreCounted(t *testing.T) {
+	sources := NewSources()
+	sources.Add("Makefile", "x\n")
+	v := violation("custom", "duplicate", 1)
+	b := New(rules.RuleViolationList{v}, sources)
+
+	ret, _ := b.Filter(rules.RuleViolationList{v, v}, sources, nil)
+	assert.Len(t, ret, 1)
+}
+
+func TestBaseline_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	makefile := filepath.Join(dir, "Makefile")
+	require.NoError(t, os.WriteFile(makefile, []byte("all:\n"), 0o644))
+
+	v := violation("phonydeclared", `Target "all" should be declared PHONY.`, 1)
+	v.FileName = makefile
+	path := filepath.Join(dir, "baseline.json")
+	require.NoError(t, New(rules.RuleViolationList{v}, NewSources()).Save(path))
+
+	b, err := Load(path)
+	require.NoError(t, err)
+	ret, stale := b.Filter(rules.RuleViolationList{v}, NewSources(), []string{makefile})
+	assert.Empty(t, ret)
+	assert.Empty(t, stale)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2}`), 0o644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "unsupported baseline version 2")
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "unable to read baseline")
+}
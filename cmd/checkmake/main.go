@@ -2,13 +2,16 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/checkmake/checkmake/baseline"
 	"github.com/checkmake/checkmake/config"
 	"github.com/checkmake/checkmake/formatters"
 	"github.com/checkmake/checkmake/logger"
@@ -17,6 +20,7 @@ import (
 	"github.com/checkmake/checkmake/validator"
 	"github.com/olekukonko/tablewriter"
 	"github.com/olekukonko/tablewriter/tw"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
 )
 
@@ -26,15 +30,27 @@ var (
 	builder   = ""
 	goversion = ""
 
-	cfgPath string
-	debug   bool
-	format  string
-	output  string
+	cfgPath        string
+	debug          bool
+	format         string
+	output         string
+	followIncludes bool
+	includeDirs    []string
+	stdinFilename  string
+	strictParse    bool
+	dialect        string
+	failOn         string
+	maxWarnings    int
+	baselinePath   string
+	writeBaseline  string
+	applyFixes     bool
+	showDiff       bool
+	sortOrder      string
 )
 
 func newRootCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:          "checkmake [flags] [makefile...]",
+		Use:          "checkmake [flags] [makefile...|-]",
 		Short:        "Validate Makefiles for common issues",
 		Long:         "checkmake scans Makefiles and reports potential issues according to configurable rules.",
 		Args:         cobra.ArbitraryArgs,
@@ -44,7 +60,7 @@ func newRootCmd() *cobra.Command {
 				_ = cmd.Help()
 				return nil
 			}
-			return runCheckmake(args)
+			return runCheckmake(cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr(), args)
 		},
 	}
 
@@ -52,7 +68,21 @@ func newRootCmd() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&cfgPath, "config", "checkmake.ini", "Configuration file to read")
 	cmd.PersistentFlags().StringVar(&format, "format", "", "Custom Go template for text output (ignored in JSON mode)")
 	cmd.PersistentFlags().StringVarP(&output, "output", "o", "text", "Output format: 'text' (default) or 'json' (mutually exclusive with --format)")
+	cmd.PersistentFlags().BoolVar(&followIncludes, "follow-includes", false, "Parse files referenced by include directives and check them as well")
+	cmd.PersistentFlags().StringSliceVarP(&includeDirs, "include-dir", "I", nil, "Additional directory to search for included files (implies --follow-includes)")
+	cmd.PersistentFlags().StringVar(&stdinFilename, "stdin-filename", "<stdin>", "File name to report violations under when reading the Makefile from stdin via '-'")
+	cmd.PersistentFlags().BoolVar(&strictParse, "strict-parse", false, "Fail if a Makefile can't be parsed cleanly, e.g. because of an unbalanced endif or a missing separator")
+	cmd.PersistentFlags().StringVar(&dialect, "dialect", "", "Dialect of make to check for: 'gnu' (default), 'posix' or 'bsd'")
+	cmd.PersistentFlags().StringVar(&failOn, "fail-on", "warning", "Lowest severity of violations that fails the run: 'error', 'warning' or 'info'")
+	cmd.PersistentFlags().IntVar(&maxWarnings, "max-warnings", -1, "Fail if there are more than this many warnings, regardless of --fail-on (-1 for no limit)")
+	cmd.PersistentFlags().StringVar(&baselinePath, "baseline", "", "Baseline file of accepted violations, only violations not in it are reported")
+	cmd.PersistentFlags().StringVar(&writeBaseline, "write-baseline", "", "Write the violations found to the given baseline file instead of reporting them")
 	cmd.MarkFlagsMutuallyExclusive("format", "output")
+	cmd.PersistentFlags().BoolVar(&applyFixes, "fix", false, "Fix the violations rules know how to repair in place and report the remaining ones")
+	cmd.PersistentFlags().BoolVar(&showDiff, "diff", false, "Print the fixes for the violations as a unified diff instead of reporting them")
+	cmd.PersistentFlags().StringVar(&sortOrder, "sort", "file", "Order of the reported violations: 'file' (by file, line, column and rule), 'rule' or 'severity'")
+	cmd.MarkFlagsMutuallyExclusive("baseline", "write-baseline")
+	cmd.MarkFlagsMutuallyExclusive("fix", "diff", "write-baseline")
 
 	cmd.Version = fmt.Sprintf("%s built at %s by %s with %s",
 		version, buildTime, builder, goversion)
@@ -67,6 +97,16 @@ func newRootCmd() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(&cobra.Command{
+		Use:   "fix [makefile...]",
+		Short: "Fix violations in place, same as --fix",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyFixes = true
+			return runCheckmake(cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr(), args)
+		},
+	})
+
 	return cmd
 }
 
@@ -110,22 +150,146 @@ func main() {
 	}
 }
 
-func runCheckmake(makefiles []string) error {
+func runCheckmake(stdin io.Reader, stdout, stderr io.Writer, makefiles []string) error {
 	cfg := loadConfig()
 	logger.Debug(fmt.Sprintf("Makefiles passed: %q", makefiles))
 
+	// Priority: dialect flag > config dialect > gnu
+	dialectName := dialect
+	if dialectName == "" {
+		if d, derr := cfg.GetConfigValue("dialect"); derr == nil {
+			dialectName = d
+		} else {
+			dialectName = "gnu"
+		}
+	}
+	makeDialect, err := parser.ParseDialect(dialectName)
+	if err != nil {
+		return err
+	}
+	logger.Debug(fmt.Sprintf("Using dialect: %s", makeDialect))
+
+	failSeverity, err := rules.ParseSeverity(failOn)
+	if err != nil {
+		return fmt.Errorf("invalid --fail-on value: %w", err)
+	}
+
+	order, err := rules.ParseSortOrder(sortOrder)
+	if err != nil {
+		return fmt.Errorf("invalid --sort value: %w", err)
+	}
+
+	var accepted *baseline.Baseline
+	if baselinePath != "" {
+		if accepted, err = baseline.Load(baselinePath); err != nil {
+			return err
+		}
+	}
+
+	parseOpts := parser.ParseOptions{
+		FollowIncludes: followIncludes || len(includeDirs) > 0,
+		IncludeDirs:    includeDirs,
+		Strict:         strictParse,
+		Dialect:        makeDialect,
+	}
+
 	var violations rules.RuleViolationList
-	for _, mkf := range makefiles {
-		logger.Info(fmt.Sprintf("Parsing file %q", mkf))
-		makefile, parseErr := parser.Parse(mkf)
-		if parseErr != nil {
-			return fmt.Errorf("failed to parse %q: %w", mkf, parseErr)
+	var parsed []parser.Makefile
+	var checked []string
+	parseErrors := 0
+	// sources are fingerprinted against the content checked last, which
+	// changes when the Makefiles are fixed
+	var sources *baseline.Sources
+	// stdin is read once, as the Makefiles are checked again after fixing
+	var stdinData []byte
+	check := func(stderr io.Writer) error {
+		violations, parsed, checked, parseErrors = nil, nil, nil, 0
+		sources = baseline.NewSources()
+		for _, mkf := range makefiles {
+			var makefile parser.Makefile
+			var parseErr error
+			if mkf == "-" {
+				logger.Info(fmt.Sprintf("Parsing stdin as %q", stdinFilename))
+				if stdinData == nil {
+					if stdinData, err = io.ReadAll(stdin); err != nil {
+						return fmt.Errorf("failed to read stdin: %w", err)
+					}
+				}
+				makefile, parseErr = parser.ParseReaderWithOptions(stdinFilename, bytes.NewReader(stdinData), parseOpts)
+				mkf = stdinFilename
+				if makefile.CST != nil {
+					sources.Add(mkf, parser.Print(makefile.CST))
+				}
+			} else {
+				logger.Info(fmt.Sprintf("Parsing file %q", mkf))
+				makefile, parseErr = parser.ParseWithOptions(mkf, parseOpts)
+			}
+			var parseErrList parser.ParseErrorList
+			if errors.As(parseErr, &parseErrList) {
+				// in strict mode the Makefile is still checked, so that all
+				// problems are reported at once
+				printParseErrors(stderr, parseErrList)
+				parseErrors += len(parseErrList)
+			} else if parseErr != nil {
+				return fmt.Errorf("failed to parse %q: %w", mkf, parseErr)
+			}
+			violations = append(violations, validator.Validate(makefile, cfg)...)
+			parsed = append(parsed, makefile)
+			checked = append(checked, mkf)
+			for _, include := range makefile.Includes {
+				checked = append(checked, include.Resolved...)
+			}
+		}
+		return nil
+	}
+	if err := check(stderr); err != nil {
+		return err
+	}
+
+	if writeBaseline != "" {
+		if err := baseline.New(violations, sources).Save(writeBaseline); err != nil {
+			return fmt.Errorf("failed to write baseline %q: %w", writeBaseline, err)
+		}
+		fmt.Fprintf(stderr, "Wrote %d violations to baseline %q\n", len(violations), writeBaseline)
+		if parseErrors > 0 {
+			return fmt.Errorf("parse errors found (%d)", parseErrors)
+		}
+		return nil
+	}
+	if accepted != nil {
+		var stale []baseline.Entry
+		violations, stale = accepted.Filter(violations, sources, checked)
+		printStaleEntries(stderr, baselinePath, stale)
+	}
+
+	if showDiff || applyFixes {
+		stdinName := ""
+		if slices.Contains(makefiles, "-") {
+			stdinName = stdinFilename
+		}
+		fixed, err := fixMakefiles(stdout, stderr, parsed, stdinName, cfg, violations)
+		if err != nil {
+			return err
+		}
+		if showDiff {
+			if parseErrors > 0 {
+				return fmt.Errorf("parse errors found (%d)", parseErrors)
+			}
+			return nil
+		}
+		// the fixed Makefiles are checked again to report what is left,
+		// parse errors have been reported already
+		if fixed > 0 {
+			if err := check(io.Discard); err != nil {
+				return err
+			}
+			if accepted != nil {
+				violations, _ = accepted.Filter(violations, sources, checked)
+			}
 		}
-		violations = append(violations, validator.Validate(makefile, cfg)...)
 	}
 
 	var formatter formatters.Formatter
-	var err error
 
 	// Priority: format flag > output flag > config format > default
 	if format != "" {
@@ -162,15 +326,112 @@ func runCheckmake(makefiles []string) error {
 		return err
 	}
 
-	// Output
+	// Output, in the same order for every formatter
+	violations.Sort(order)
 	if len(violations) > 0 {
 		formatter.Format(violations)
-		return fmt.Errorf("violations found (%d)", len(violations))
+	}
+	if parseErrors > 0 {
+		return fmt.Errorf("parse errors found (%d)", parseErrors)
+	}
+	failing, warnings := 0, 0
+	for _, violation := range violations {
+		if violation.Severity.AtLeast(failSeverity) {
+			failing++
+		}
+		if violation.Severity == rules.SeverityWarning {
+			warnings++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("violations found (%d)", failing)
+	}
+	if maxWarnings >= 0 && warnings > maxWarnings {
+		return fmt.Errorf("too many warnings (%d, at most %d allowed)", warnings, maxWarnings)
 	}
 
 	return nil
 }
 
+// printParseErrors writes every parse error along with the offending line
+func printParseErrors(w io.Writer, parseErrors parser.ParseErrorList) {
+	for _, parseErr := range parseErrors {
+		fmt.Fprintln(w, parseErr.Error())
+		for _, line := range strings.Split(parseErr.Snippet, "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+}
+
+// fixMakefiles applies the fixes for the violations to the Makefiles, or
+// prints them as a unified diff with --diff, and returns the number of
+// Makefiles changed. The Makefile read from stdin, reported under
+// stdinName, can only be fixed with --diff.
+func fixMakefiles(stdout, stderr io.Writer, makefiles []parser.Makefile, stdinName string, cfg *config.Config, violations rules.RuleViolationList) (int, error) {
+	fixed := 0
+	for _, makefile := range makefiles {
+		edits := validator.Fix(makefile, cfg, violations)
+		if len(edits) == 0 || makefile.CST == nil {
+			continue
+		}
+		content := parser.Print(makefile.CST)
+		changed, err := validator.ApplyEdits(content, edits)
+		if err != nil {
+			return fixed, fmt.Errorf("failed to fix %q: %w", makefile.FileName, err)
+		}
+
+		if showDiff {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        splitLines(content),
+				B:        splitLines(changed),
+				FromFile: makefile.FileName,
+				ToFile:   makefile.FileName,
+				Context:  3,
+			})
+			if err != nil {
+				return fixed, err
+			}
+			fmt.Fprint(stdout, diff)
+			continue
+		}
+
+		if stdinName != "" && makefile.FileName == stdinName {
+			fmt.Fprintf(stderr, "Unable to write fixes for %q read from stdin, use --diff instead\n", makefile.FileName)
+			continue
+		}
+		info, err := os.Stat(makefile.FileName)
+		if err != nil {
+			return fixed, fmt.Errorf("failed to write fixes to %q: %w", makefile.FileName, err)
+		}
+		if err := os.WriteFile(makefile.FileName, []byte(changed), info.Mode()); err != nil {
+			return fixed, fmt.Errorf("failed to write fixes to %q: %w", makefile.FileName, err)
+		}
+		logger.Info(fmt.Sprintf("Fixed %d violations in %q", len(edits), makefile.FileName))
+		fixed++
+	}
+	return fixed, nil
+}
+
+// splitLines splits text into lines, keeping their line terminators
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// printStaleEntries writes the baseline entries which don't match any
+// violation anymore
+func printStaleEntries(w io.Writer, path string, stale []baseline.Entry) {
+	for _, entry := range stale {
+		fmt.Fprintf(w, "%s: stale entry %s:%d: %s: %s\n", path, entry.FileName, entry.LineNumber, entry.Rule, entry.Violation)
+	}
+	if len(stale) > 0 {
+		fmt.Fprintf(w, "%s: %d violations were fixed, update the baseline with --write-baseline\n", path, len(stale))
+	}
+}
+
 func listRules(w io.Writer, cfg *config.Config) {
 	rulesSorted := rules.GetRulesSorted()
 	data := make([][]string, len(rulesSorted))
@@ -7,6 +7,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,6 +38,27 @@ func captureOutput(f func()) string {
 	return buf.String()
 }
 
+// execute runs checkmake with the given arguments and returns what it wrote
+// to stdout and stderr
+func execute(args ...string) (stdout, stderr string, err error) {
+	return executeWithStdin("", args...)
+}
+
+// executeWithStdin runs checkmake like execute, reading stdin from the
+// given string
+func executeWithStdin(stdin string, args ...string) (stdout, stderr string, err error) {
+	var errOut bytes.Buffer
+	stdout = captureOutput(func() {
+		cmd := newRootCmd()
+		cmd.SilenceErrors = true
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetErr(&errOut)
+		cmd.SetArgs(args)
+		err = cmd.Execute()
+	})
+	return stdout, errOut.String(), err
+}
+
 func TestCheckmake_NoArgsShowsHelp(t *testing.T) {
 	out := captureOutput(func() {
 		cmd := newRootCmd()
@@ -62,7 +85,7 @@ func TestCheckmake_VersionOutput(t *testing.T) {
 func TestCheckmake_RunWithSimpleMakefile(t *testing.T) {
 	t.Parallel()
 	cmd := newRootCmd()
-	cmd.SetArgs([]string{"../../fixtures/simple.make"})
+	cmd.SetArgs([]string{"../../fixtures/all_targets_present.make"})
 
 	var buf bytes.Buffer
 	cmd.SetOut(&buf)
@@ -132,7 +155,7 @@ func TestCheckmake_WithCustomFormatFlag(t *testing.T) {
 
 	// There are three expected rules in missing_phony.make: phonydeclared and minphony twice
 	assert.Contains(t, out, "phonydeclared on 16")
-	assert.Contains(t, out, "minphony on 22")
+	assert.Contains(t, out, "minphony on 21")
 }
 
 func TestCheckmake_DebugLogsMakefilesPassed(t *testing.T) {
@@ -335,3 +358,252 @@ func TestCheckmake_FormatAndOutputAreMutuallyExclusive(t *testing.T) {
 	assert.Contains(t, errorOutput, "[format output]", "should reference the conflicting flags")
 	assert.Contains(t, errorOutput, "can be", "should mention that flags cannot be set together")
 }
+
+func TestCheckmake_FollowIncludes(t *testing.T) {
+	out, _, err := execute(
+		"--format", "{{.FileName}}:{{.LineNumber}}:{{.Rule}}:{{.Violation}}",
+		"-I", "../../fixtures/includes/shared",
+		"../../fixtures/includes/Makefile",
+	)
+	require.Error(t, err, "expected the missing include to be reported")
+
+	t.Logf("include output:\n%s", out)
+
+	assert.Contains(t, out, `../../fixtures/includes/Makefile:4:missinginclude:Included file "mk/missing.mk" does not exist.`)
+	assert.Contains(t, out, `../../fixtures/includes/mk/targets.mk:12:missinginclude:Included file "../../fixtures/includes/Makefile" is already being parsed (include cycle).`)
+	assert.NotContains(t, out, "common.mk", "common.mk should be found via the include dir")
+	assert.NotContains(t, out, "minphony", "targets from included files should satisfy minphony")
+}
+
+func TestCheckmake_ReadFromStdin(t *testing.T) {
+	out, _, err := executeWithStdin(
+		".PHONY: all clean test\nall:\n\t@echo all\nclean:\n\t@echo clean\ntest:\n\t@echo test\nbuild: all\n",
+		"--format", "{{.FileName}}:{{.LineNumber}}:{{.Rule}}",
+		"--stdin-filename", "editor/Makefile",
+		"-",
+	)
+	require.Error(t, err, "expected violations for the Makefile read from stdin")
+
+	assert.Equal(t, "editor/Makefile:8:phonydeclared\n", out)
+}
+
+func TestCheckmake_TargetSpecificVariables(t *testing.T) {
+	out, _, err := executeWithStdin(
+		".PHONY: all clean test\nall: CFLAGS += -g\ndebug: override LDFLAGS := -s\nall:\n\t@echo all\nclean:\n\t@echo clean\ntest:\n\t@echo test\n",
+		"--format", "{{.FileName}}:{{.LineNumber}}:{{.Rule}}",
+		"-",
+	)
+	require.NoError(t, err, "target-specific variables should not be reported as rules")
+
+	assert.Empty(t, out)
+}
+
+func TestCheckmake_UnrecognizedLines(t *testing.T) {
+	out, _, err := execute(
+		"--format", "{{.FileName}}:{{.LineNumber}}:{{.Rule}}:{{.Violation}}",
+		"../../fixtures/unknown_lines.make",
+	)
+	require.Error(t, err, "expected the unrecognized line to fail the lint")
+
+	assert.Equal(t, `../../fixtures/unknown_lines.make:4:unrecognizedline:Line "thisisnotarule" could not be parsed: neither a rule nor a variable.`+"\n", out)
+}
+
+func TestCheckmake_StrictParse(t *testing.T) {
+	out, stderr, err := execute(
+		"--strict-parse",
+		"--format", "{{.LineNumber}}:{{.Rule}}",
+		"../../fixtures/unknown_lines.make",
+	)
+
+	require.EqualError(t, err, "parse errors found (1)")
+	assert.Equal(t, "../../fixtures/unknown_lines.make:4:1: neither a rule nor a variable\n    thisisnotarule\n", stderr)
+	assert.Equal(t, "4:unrecognizedline\n", out, "violations are reported in strict mode as well")
+}
+
+func TestCheckmake_StrictParseWithCleanMakefile(t *testing.T) {
+	_, stderr, err := execute("--strict-parse", "../../fixtures/all_targets_present.make")
+	require.NoError(t, err)
+	assert.Empty(t, stderr)
+}
+
+func TestCheckmake_BSDDialect(t *testing.T) {
+	out, _, err := execute("--format", "{{.LineNumber}}:{{.Rule}}", "../../fixtures/bsd.make")
+	require.Error(t, err, "BSD conditionals aren't understood by GNU make")
+	assert.Equal(t, "5:unrecognizedline\n7:unrecognizedline\n", out)
+
+	_, _, err = execute("--dialect", "bsd", "--strict-parse", "../../fixtures/bsd.make")
+	require.NoError(t, err)
+}
+
+func TestCheckmake_PosixDialect(t *testing.T) {
+	out, _, err := execute("--dialect", "posix", "--format", "{{.LineNumber}}:{{.Rule}}:{{.Violation}}", "../../fixtures/simple.make")
+	require.EqualError(t, err, "violations found (2)")
+	assert.Equal(t, "4:gnuextensions:GNU make extension not supported by POSIX make: ':=' assignment of \"simple\", use '::='.\n"+
+		"19:unrecognizedline:Line \"@echo lolnah\" could not be parsed: recipe line indented with spaces.\n", out)
+}
+
+func TestCheckmake_DialectFromConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "checkmake.ini")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("[default]\ndialect = bsd\n"), 0o644))
+
+	_, _, err := execute("--config", cfgFile, "--strict-parse", "../../fixtures/bsd.make")
+	require.NoError(t, err)
+}
+
+func TestCheckmake_InvalidDialect(t *testing.T) {
+	_, _, err := execute("--dialect", "nmake", "../../fixtures/simple.make")
+	require.EqualError(t, err, `invalid dialect: "nmake" (supported: gnu, posix, bsd)`)
+}
+
+func TestCheckmake_FailOn(t *testing.T) {
+	// missing_phony.make only has warnings
+	run := func(args ...string) error {
+		_, _, err := execute(append(args, "../../fixtures/missing_phony.make")...)
+		return err
+	}
+
+	require.EqualError(t, run(), "violations found (3)")
+	require.EqualError(t, run("--fail-on", "info"), "violations found (3)")
+	require.NoError(t, run("--fail-on", "error"))
+	require.NoError(t, run("--fail-on", "error", "--max-warnings", "3"))
+	require.EqualError(t, run("--fail-on", "error", "--max-warnings", "2"), "too many warnings (3, at most 2 allowed)")
+	require.EqualError(t, run("--fail-on", "fatal"), `invalid --fail-on value: invalid severity "fatal" (supported: error, warning, info)`)
+}
+
+func TestCheckmake_SeverityFromConfig(t *testing.T) {
+	cfgFile := filepath.Join(t.TempDir(), "checkmake.ini")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("[phonydeclared]\nseverity = error\n[minphony]\nseverity = info\n"), 0o644))
+
+	out, _, err := execute("--config", cfgFile, "--fail-on", "error", "--format", "{{.Severity}}:{{.Rule}}", "../../fixtures/missing_phony.make")
+	require.EqualError(t, err, "violations found (1)")
+	assert.Contains(t, out, "error:phonydeclared\n")
+	assert.Contains(t, out, "info:minphony\n")
+}
+
+func TestCheckmake_Baseline(t *testing.T) {
+	dir := t.TempDir()
+	makefile := filepath.Join(dir, "Makefile")
+	data, err := os.ReadFile("../../fixtures/missing_phony.make")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(makefile, data, 0o644))
+	baselineFile := filepath.Join(dir, "baseline.json")
+
+	run := func(args ...string) (string, string, error) {
+		return execute(append(args, "--format", "{{.Rule}}", makefile)...)
+	}
+
+	_, stderr, err := run("--write-baseline", baselineFile)
+	require.NoError(t, err)
+	assert.Contains(t, stderr, "Wrote 3 violations to baseline")
+
+	// violations which only moved are still accepted
+	require.NoError(t, os.WriteFile(makefile, append([]byte("# header\n\n"), data...), 0o644))
+	out, stderr, err := run("--baseline", baselineFile)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+	assert.Empty(t, stderr)
+
+	require.NoError(t, os.WriteFile(makefile, append(data, []byte("\ninstall:\n")...), 0o644))
+	out, _, err = run("--baseline", baselineFile)
+	require.EqualError(t, err, "violations found (1)")
+	assert.Equal(t, "phonydeclared\n", out)
+
+	require.NoError(t, os.WriteFile(makefile, []byte(".PHONY: all test clean\nall:\ntest:\nclean:\n"), 0o644))
+	_, stderr, err = run("--baseline", baselineFile)
+	require.NoError(t, err)
+	assert.Contains(t, stderr, `stale entry `+filepath.ToSlash(makefile)+`:16: phonydeclared: Target "all" should be declared PHONY.`)
+	assert.Contains(t, stderr, "3 violations were fixed")
+
+	_, _, err = run("--baseline", filepath.Join(dir, "missing.json"))
+	require.ErrorContains(t, err, "unable to read baseline")
+}
+
+func TestCheckmake_FixAndDiff(t *testing.T) {
+	makefile := filepath.Join(t.TempDir(), "Makefile")
+	content := ".PHONY: all\nall:\nclean:\ntest:\nNOW = $(shell date)\n"
+	require.NoError(t, os.WriteFile(makefile, []byte(content), 0o644))
+
+	out, _, err := execute("--diff", makefile)
+	require.NoError(t, err)
+	assert.Contains(t, out, "-.PHONY: all\n+.PHONY: all clean test\n")
+	assert.Contains(t, out, "-NOW = $(shell date)\n+NOW := $(shell date)\n")
+	data, _ := os.ReadFile(makefile)
+	assert.Equal(t, content, string(data), "--diff must not change the file")
+
+	out, _, err = execute("fix", makefile)
+	require.NoError(t, err, "all violations should be fixed")
+	assert.Empty(t, out)
+	data, _ = os.ReadFile(makefile)
+	assert.Equal(t, ".PHONY: all clean test\nall:\nclean:\ntest:\nNOW := $(shell date)\n", string(data))
+}
+
+func TestCheckmake_FixReportsRemainingViolations(t *testing.T) {
+	makefile := filepath.Join(t.TempDir(), "Makefile")
+	require.NoError(t, os.WriteFile(makefile, []byte("all:\nall:\n"), 0o644))
+
+	out, _, err := execute("--fix", "--format", "{{.Rule}}", makefile)
+	require.Error(t, err)
+	assert.NotContains(t, out, "phonydeclared")
+	assert.Contains(t, out, "uniquetargets")
+	data, _ := os.ReadFile(makefile)
+	assert.Equal(t, ".PHONY: all\nall:\nall:\n", string(data))
+}
+
+func TestCheckmake_FixWithBaseline(t *testing.T) {
+	dir := t.TempDir()
+	makefile := filepath.Join(dir, "Makefile")
+	baselineFile := filepath.Join(dir, "baseline.json")
+	cfgFile := filepath.Join(dir, "checkmake.ini")
+	require.NoError(t, os.WriteFile(cfgFile, []byte("[minphony]\ndisabled = true\n"), 0o644))
+	body := "build:\n\techo 1\n\techo 2\n\techo 3\n\techo 4\n\techo 5\n\techo 6\n"
+	require.NoError(t, os.WriteFile(makefile, []byte(body), 0o644))
+
+	_, _, err := execute("--config", cfgFile, "--write-baseline", baselineFile, makefile)
+	require.NoError(t, err)
+
+	// the baselined violation moves down when the fix declares all phony,
+	// it must still be accepted when the fixed Makefile is checked again
+	require.NoError(t, os.WriteFile(makefile, []byte("all: build\n"+body), 0o644))
+	out, _, err := execute("--config", cfgFile, "--baseline", baselineFile, "--fix", "--format", "{{.Rule}}", makefile)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+	data, _ := os.ReadFile(makefile)
+	assert.Equal(t, ".PHONY: all\nall: build\n"+body, string(data))
+}
+
+func TestCheckmake_FixFromStdin(t *testing.T) {
+	out, _, err := executeWithStdin(".PHONY: all test clean\nall:\ntest:\nclean:\ninstall:\n", "--diff", "--stdin-filename", "Makefile", "-")
+	require.NoError(t, err)
+	assert.Contains(t, out, "+.PHONY: all test clean install\n")
+
+	_, stderr, _ := executeWithStdin("all:\n", "--fix", "-")
+	assert.Contains(t, stderr, `Unable to write fixes for "<stdin>" read from stdin, use --diff instead`)
+}
+
+func TestCheckmake_Sort(t *testing.T) {
+	makefile := filepath.Join(t.TempDir(), "Makefile")
+	require.NoError(t, os.WriteFile(makefile, []byte(".PHONY: all clean test\nall:\nclean:\ntest:\nall:\nNOW = $(shell date)\n"), 0o644))
+
+	run := func(args ...string) (string, error) {
+		out, _, err := execute(append(args, makefile)...)
+		return out, err
+	}
+
+	out, _ := run("--format", "{{.LineNumber}}:{{.Rule}}")
+	assert.Equal(t, "5:uniquetargets\n6:timestampexpanded\n", out)
+	out, _ = run("--sort", "rule", "--format", "{{.LineNumber}}:{{.Rule}}")
+	assert.Equal(t, "6:timestampexpanded\n5:uniquetargets\n", out)
+	out, _ = run("--sort", "severity", "--format", "{{.Severity}}:{{.Rule}}")
+	assert.Equal(t, "error:uniquetargets\nwarning:timestampexpanded\n", out)
+
+	out, _ = run("--sort", "rule", "-o", "json")
+	var violations []struct {
+		Rule string `json:"rule"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &violations))
+	require.Len(t, violations, 2)
+	assert.Equal(t, "timestampexpanded", violations[0].Rule)
+
+	_, err := run("--sort", "name")
+	require.EqualError(t, err, `invalid --sort value: invalid sort order "name" (supported: file, rule, severity)`)
+}
@@ -0,0 +1,12 @@
+VERSION := 1.0
+
+clean:
+	rm -rf build
+
+test:
+	@echo test
+
+build:
+	touch build
+
+include ../Makefile
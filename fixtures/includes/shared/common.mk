@@ -0,0 +1 @@
+SHARED := yes
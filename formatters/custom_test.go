@@ -23,8 +23,8 @@ func TestCustomFormatter(t *testing.T) {
 
 	violations := validator.Validate(makefile, &config.Config{})
 	formatter.Format(violations)
-	assert.Regexp(t, `../fixtures/missing_phony.make:22:minphony:Required target "all" must be declared PHONY.`, out.String())
-	assert.Regexp(t, `../fixtures/missing_phony.make:22:minphony:Required target "test" must be declared PHONY.`, out.String())
+	assert.Regexp(t, `../fixtures/missing_phony.make:21:minphony:Required target "all" must be declared PHONY.`, out.String())
+	assert.Regexp(t, `../fixtures/missing_phony.make:21:minphony:Required target "test" must be declared PHONY.`, out.String())
 	assert.Regexp(t, `../fixtures/missing_phony.make:16:phonydeclared:Target "all" should be declared PHONY.`, out.String())
 	assert.Equal(t, strings.Count(out.String(), "\n"), 3)
 }
@@ -42,3 +42,32 @@ func TestCustomFormatterNewMethodFailing(t *testing.T) {
 
 	assert.NotEqual(t, nil, err)
 }
+
+func TestCustomFormatterWithColumns(t *testing.T) {
+	t.Parallel()
+	out := new(bytes.Buffer)
+
+	tmpl, _ := template.New("test").Parse("{{.FileName}}:{{.LineNumber}}:{{.Column}}-{{.EndLineNumber}}:{{.EndColumn}}:{{.Rule}}")
+	formatter := CustomFormatter{template: tmpl, out: out}
+
+	makefile, _ := parser.Parse("../fixtures/missing_phony.make")
+
+	violations := validator.Validate(makefile, &config.Config{})
+	formatter.Format(violations)
+	assert.Contains(t, out.String(), "../fixtures/missing_phony.make:16:1-16:9:phonydeclared")
+	assert.Contains(t, out.String(), "../fixtures/missing_phony.make:21:1-21:14:minphony")
+}
+
+func TestCustomFormatterWithSeverity(t *testing.T) {
+	t.Parallel()
+	out := new(bytes.Buffer)
+	tmpl, _ := template.New("test").Parse("{{.Severity}}:{{.Rule}}")
+	formatter := CustomFormatter{template: tmpl, out: out}
+
+	makefile, _ := parser.Parse("../fixtures/missing_phony.make")
+	violations := validator.Validate(makefile, &config.Config{})
+	formatter.Format(violations)
+
+	assert.Contains(t, out.String(), "warning:phonydeclared\n")
+	assert.Contains(t, out.String(), "warning:minphony\n")
+}
@@ -25,9 +25,11 @@ func NewDefaultFormatter() *DefaultFormatter {
 func (f *DefaultFormatter) Format(violations rules.RuleViolationList) {
 	data := make([][]string, len(violations))
 
+	// the severity goes below the rule name, which is wider, so the
+	// description keeps its width
 	for idx, val := range violations {
 		data[idx] = []string{
-			val.Rule,
+			val.Rule + "\n" + val.Severity.String(),
 			val.Violation,
 			val.FileName,
 			strconv.Itoa(val.LineNumber),
@@ -6,6 +6,7 @@ import (
 
 	"github.com/checkmake/checkmake/config"
 	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
 	"github.com/checkmake/checkmake/validator"
 	"github.com/stretchr/testify/assert"
 )
@@ -23,3 +24,15 @@ func TestDefaultFormatter(t *testing.T) {
 	assert.Regexp(t, `(?s)phonydeclared\s+Target "all".+\s+16`, out.String())
 	assert.Regexp(t, `(?s)declared\s+PHONY`, out.String())
 }
+
+func TestDefaultFormatterWithSeverity(t *testing.T) {
+	out := new(bytes.Buffer)
+	formatter := DefaultFormatter{out: out}
+
+	formatter.Format(rules.RuleViolationList{
+		{Rule: "uniquetargets", Violation: "duplicate", FileName: "Makefile", LineNumber: 7, Severity: rules.SeverityError},
+	})
+
+	// the severity is listed below the rule name
+	assert.Regexp(t, `uniquetargets\s+duplicate\s+Makefile\s+7\s+\n\s*error\s`, out.String())
+}
@@ -22,19 +22,27 @@ func NewJSONFormatter() *JSONFormatter {
 func (f *JSONFormatter) Format(violations rules.RuleViolationList) {
 	// Convert violations to JSON-serializable structure
 	type ViolationJSON struct {
-		Rule       string `json:"rule"`
-		Violation  string `json:"violation"`
-		FileName   string `json:"file_name"`
-		LineNumber int    `json:"line_number"`
+		Rule          string `json:"rule"`
+		Violation     string `json:"violation"`
+		FileName      string `json:"file_name"`
+		LineNumber    int    `json:"line_number"`
+		Column        int    `json:"column,omitempty"`
+		EndLineNumber int    `json:"end_line_number,omitempty"`
+		EndColumn     int    `json:"end_column,omitempty"`
+		Severity      string `json:"severity"`
 	}
 
 	violationsJSON := make([]ViolationJSON, len(violations))
 	for i, v := range violations {
 		violationsJSON[i] = ViolationJSON{
-			Rule:       v.Rule,
-			Violation:  v.Violation,
-			FileName:   v.FileName,
-			LineNumber: v.LineNumber,
+			Rule:          v.Rule,
+			Violation:     v.Violation,
+			FileName:      v.FileName,
+			LineNumber:    v.LineNumber,
+			Column:        v.Column(),
+			EndLineNumber: v.EndLineNumber(),
+			EndColumn:     v.EndColumn(),
+			Severity:      v.Severity.String(),
 		}
 	}
 
@@ -69,3 +69,57 @@ func TestJSONFormatter_EmptyViolations(t *testing.T) {
 	require.NoError(t, err, "output should be valid JSON even with no violations")
 	assert.Equal(t, 0, len(violations), "should have empty array for no violations")
 }
+
+func TestJSONFormatter_Positions(t *testing.T) {
+	out := new(bytes.Buffer)
+	formatter := JSONFormatter{out: out}
+
+	formatter.Format(rules.RuleViolationList{
+		{
+			Rule:       "phonydeclared",
+			Violation:  "Target \"all\" should be declared PHONY.",
+			FileName:   "Makefile",
+			LineNumber: 3,
+			Range: parser.Range{
+				FileName: "Makefile",
+				Start:    parser.Position{Line: 3, Column: 1},
+				End:      parser.Position{Line: 4, Column: 12},
+			},
+		},
+		{
+			Rule:       "minphony",
+			Violation:  "Required target \"test\" is missing from the Makefile.",
+			FileName:   "Makefile",
+			LineNumber: -1,
+		},
+	})
+
+	var violationsJSON []map[string]interface{}
+	err := json.Unmarshal(out.Bytes(), &violationsJSON)
+	require.NoError(t, err, "output should be valid JSON")
+	require.Len(t, violationsJSON, 2)
+
+	assert.Equal(t, float64(1), violationsJSON[0]["column"])
+	assert.Equal(t, float64(4), violationsJSON[0]["end_line_number"])
+	assert.Equal(t, float64(12), violationsJSON[0]["end_column"])
+
+	assert.NotContains(t, violationsJSON[1], "column", "unknown positions should be omitted")
+	assert.NotContains(t, violationsJSON[1], "end_line_number")
+	assert.NotContains(t, violationsJSON[1], "end_column")
+}
+
+func TestJSONFormatter_Severity(t *testing.T) {
+	out := new(bytes.Buffer)
+	formatter := JSONFormatter{out: out}
+
+	formatter.Format(rules.RuleViolationList{
+		{Rule: "maxbodylength", Violation: "too long", FileName: "Makefile", LineNumber: 3, Severity: rules.SeverityWarning},
+		{Rule: "custom", Violation: "no severity", FileName: "Makefile", LineNumber: 4},
+	})
+
+	var violations []map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &violations))
+	require.Len(t, violations, 2)
+	assert.Equal(t, "warning", violations[0]["severity"])
+	assert.Equal(t, "error", violations[1]["severity"], "an empty severity is an error")
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// reFindBSDConditional captures the opening directive of a BSD make
+	// conditional block. Whitespace is allowed between the dot and the
+	// keyword, which is commonly used to indent nested conditionals.
+	// Group 1: The keyword (if, ifdef, ifndef, ifmake, ifnmake).
+	// Group 2: The condition, e.g. "${OPSYS} == NetBSD".
+	reFindBSDConditional = regexp.MustCompile(`^\.\s*(if|ifdef|ifndef|ifmake|ifnmake)(?:\s+(.*))?$`)
+
+	// reFindBSDElse captures the else clauses of BSD make conditionals.
+	// Group 1: The keyword (else, elif, elifdef, elifndef, elifmake,
+	//          elifnmake).
+	// Group 2: The condition of an elif clause.
+	reFindBSDElse = regexp.MustCompile(`^\.\s*(else|elif|elifdef|elifndef|elifmake|elifnmake)(?:\s+(.*))?$`)
+
+	// reFindBSDEndif matches the end of a BSD make conditional block.
+	reFindBSDEndif = regexp.MustCompile(`^\.\s*endif(?:\s.*)?$`)
+
+	// reFindBSDInclude captures BSD make include directives.
+	// Group 1: The keyword (include, -include, sinclude or dinclude).
+	// Group 2: The file to include, e.g. "<bsd.prog.mk>" or "\"config.mk\"".
+	reFindBSDInclude = regexp.MustCompile(`^\.\s*(include|-include|sinclude|dinclude)\s+(.*)$`)
+
+	// reFindBSDDirective matches the other directives of BSD make, which
+	// don't define rules or variables.
+	reFindBSDDirective = regexp.MustCompile(`^\.\s*(for|endfor|break|undef|error|warning|info|export|export-env|export-literal|unexport|unexport-env)(?:\s.*)?$`)
+)
+
+// isBSDConditional reports whether the line is one of the BSD make
+// directives that open, continue or close a conditional block
+func isBSDConditional(line string) bool {
+	line, _ = splitComment(line)
+	return reFindBSDConditional.MatchString(line) || reFindBSDElse.MatchString(line) ||
+		reFindBSDEndif.MatchString(line)
+}
+
+// handleBSD processes a BSD make conditional directive spanning the given
+// range. The directives are recorded with their leading dot, e.g. ".if".
+func (s *conditionalStack) handleBSD(line string, rng Range) *ParseError {
+	line, _ = splitComment(line)
+	if matches := reFindBSDConditional.FindStringSubmatch(line); matches != nil {
+		s.open("."+matches[1], strings.TrimSpace(matches[2]), rng)
+		return nil
+	}
+
+	if matches := reFindBSDElse.FindStringSubmatch(line); matches != nil {
+		directive := ""
+		if matches[1] != "else" {
+			directive = "." + matches[1]
+		}
+		return s.branch("."+matches[1], directive, strings.TrimSpace(matches[2]), line, rng)
+	}
+
+	return s.end(".endif", line, rng)
+}
+
+// isBSDInclude reports whether the line is a BSD make include directive
+func isBSDInclude(line string) bool {
+	return reFindBSDInclude.MatchString(line)
+}
+
+// parseBSDInclude parses a BSD make include directive spanning the given
+// range. A file in angle brackets is a system include, which is only looked
+// up in the include directories.
+func parseBSDInclude(line string, rng Range) Include {
+	line, _ = splitComment(line)
+	matches := reFindBSDInclude.FindStringSubmatch(line)
+	path := strings.TrimSpace(matches[2])
+
+	ret := Include{
+		Directive:  "." + matches[1],
+		Optional:   matches[1] != "include",
+		FileName:   rng.FileName,
+		LineNumber: rng.Start.Line,
+		Range:      rng,
+	}
+	switch {
+	case len(path) >= 2 && path[0] == '<' && path[len(path)-1] == '>':
+		ret.System = true
+		ret.Paths = []string{path[1 : len(path)-1]}
+	case len(path) >= 2 && path[0] == '"' && path[len(path)-1] == '"':
+		ret.Paths = []string{path[1 : len(path)-1]}
+	default:
+		ret.Paths = strings.Fields(path)
+	}
+	return ret
+}
+
+// isBSDDirective reports whether the line is any other BSD make directive,
+// like .for or .undef
+func isBSDDirective(line string) bool {
+	return reFindBSDDirective.MatchString(line)
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bsdMakefile = `PROG = hello
+SRCS = hello.c
+.if ${OPSYS} == NetBSD  # comment
+CFLAGS += -DNETBSD
+.  ifdef DEBUG
+CFLAGS += -g
+.  endif
+.elif ${OPSYS} == FreeBSD
+CFLAGS += -DFREEBSD
+.else
+CFLAGS += -DOTHER
+.endif
+.for f in a b
+${f}.o: ${f}.c
+.endfor
+.undef SRCS
+VERSION != git describe
+.include "config.mk"
+.-include <local.mk>
+.include <bsd.prog.mk>
+`
+
+func TestParse_BSDDialect(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseReaderWithOptions("Makefile", strings.NewReader(bsdMakefile),
+		ParseOptions{Dialect: BSD, Strict: true})
+	require.NoError(t, err)
+	assert.Equal(t, BSD, ret.Dialect)
+	assert.Empty(t, ret.Diagnostics)
+
+	require.Len(t, ret.Conditionals, 1)
+	conditional := ret.Conditionals[0]
+	require.Len(t, conditional.Branches, 3)
+	assert.Equal(t, ".if", conditional.Branches[0].Directive)
+	assert.Equal(t, "${OPSYS} == NetBSD", conditional.Branches[0].Condition)
+	assert.Equal(t, ".elif", conditional.Branches[1].Directive)
+	assert.Equal(t, "${OPSYS} == FreeBSD", conditional.Branches[1].Condition)
+	assert.Empty(t, conditional.Branches[2].Directive)
+	assert.Equal(t, 12, conditional.EndLineNumber)
+
+	require.Len(t, conditional.Branches[0].Conditionals, 1)
+	assert.Equal(t, ".ifdef", conditional.Branches[0].Conditionals[0].Branches[0].Directive)
+	assert.Equal(t, "DEBUG", conditional.Branches[0].Conditionals[0].Branches[0].Condition)
+
+	require.Len(t, ret.Rules, 1)
+	assert.Equal(t, "${f}.o", ret.Rules[0].Target)
+
+	require.Len(t, ret.Variables, 7)
+	assert.Equal(t, "VERSION", ret.Variables[6].Name)
+	assert.Equal(t, "!=", ret.Variables[6].Operator)
+
+	require.Len(t, ret.Includes, 3)
+	assert.Equal(t, Include{
+		Directive:  ".include",
+		Paths:      []string{"config.mk"},
+		FileName:   "Makefile",
+		LineNumber: 18,
+		Range:      Range{FileName: "Makefile", Start: Position{18, 1}, End: Position{18, 21}},
+	}, ret.Includes[0])
+	assert.Equal(t, ".-include", ret.Includes[1].Directive)
+	assert.True(t, ret.Includes[1].Optional)
+	assert.True(t, ret.Includes[1].System)
+	assert.Equal(t, []string{"local.mk"}, ret.Includes[1].Paths)
+	assert.False(t, ret.Includes[2].Optional)
+	assert.True(t, ret.Includes[2].System)
+}
+
+func TestParse_BSDDirectivesInGNUDialect(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseReader("Makefile", strings.NewReader(".if defined(DEBUG)\nCFLAGS += -g\n.endif\n"))
+	require.NoError(t, err)
+	assert.Empty(t, ret.Conditionals)
+	assert.Len(t, ret.Diagnostics, 2)
+}
+
+func TestParse_BSDUnbalancedConditionals(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseReaderWithOptions("Makefile", strings.NewReader(".endif\n.if 1\n.else\n"),
+		ParseOptions{Dialect: BSD})
+	require.NoError(t, err)
+	require.Len(t, ret.Errors, 2)
+	assert.Equal(t, "'.endif' without matching conditional", ret.Errors[0].Message)
+	assert.Equal(t, "'.if' is never closed by '.endif'", ret.Errors[1].Message)
+}
+
+func TestParse_BSDSystemIncludes(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"Makefile":        {Data: []byte(".include <sys.mk>\n.include \"sys.mk\"\n")},
+		"sys.mk":          {Data: []byte("LOCAL = yes\n")},
+		"share/mk/sys.mk": {Data: []byte("SYSTEM = yes\n")},
+	}
+	ret, err := ParseWithOptions("Makefile", ParseOptions{
+		FS:             fsys,
+		FollowIncludes: true,
+		IncludeDirs:    []string{"share/mk"},
+		Dialect:        BSD,
+	})
+	require.NoError(t, err)
+	require.Len(t, ret.Includes, 2)
+	assert.Equal(t, []string{"share/mk/sys.mk"}, ret.Includes[0].Resolved)
+	assert.Equal(t, []string{"sys.mk"}, ret.Includes[1].Resolved)
+	require.Len(t, ret.Variables, 2)
+	assert.Equal(t, "SYSTEM", ret.Variables[0].Name)
+	assert.Equal(t, "LOCAL", ret.Variables[1].Name)
+}
+
+func TestParseDialect(t *testing.T) {
+	t.Parallel()
+	for name, expected := range map[string]Dialect{"gnu": GNU, "POSIX": POSIX, " bsd ": BSD} {
+		dialect, err := ParseDialect(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, dialect)
+	}
+	assert.Equal(t, "bsd", BSD.String())
+
+	_, err := ParseDialect("nmake")
+	assert.EqualError(t, err, `invalid dialect: "nmake" (supported: gnu, posix, bsd)`)
+}
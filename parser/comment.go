@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"strings"
+)
+
+// Comment represents a comment, either on a line of its own or trailing a
+// rule or variable definition
+type Comment struct {
+	// Text is the comment without the leading "#" and surrounding whitespace
+	Text       string
+	FileName   string
+	LineNumber int
+	Range      Range
+}
+
+// CommentList represents a list of comments
+type CommentList []Comment
+
+// isComment reports whether the line is a comment line
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " \t"), "#")
+}
+
+// parseComment parses the comment line the scanner resides on
+func parseComment(scanner *MakefileScanner) Comment {
+	return Comment{
+		Text:       commentText(strings.TrimLeft(scanner.Text(), " \t")),
+		FileName:   scanner.FileName,
+		LineNumber: scanner.LineNumber,
+		Range:      scanner.Range(),
+	}
+}
+
+// commentText strips the "#" characters and whitespace off a comment
+func commentText(comment string) string {
+	return strings.TrimSpace(strings.TrimLeft(comment, "#"))
+}
+
+// indexComment returns the index of the "#" starting a comment in the line,
+// or -1 if there is none. Escaped "\#" and "#" within variable references or
+// function calls don't start a comment. If one of the stop chars comes
+// first, the rest of the line isn't searched, e.g. the ";" starting an
+// inline recipe, which is passed to the shell as it is.
+func indexComment(line, stop string) int {
+	idx := indexOutsideReferences(line, "#"+stop)
+	for idx != -1 {
+		if line[idx] != '#' {
+			return -1
+		}
+		backslashes := idx - len(strings.TrimRight(line[:idx], "\\"))
+		if backslashes%2 == 0 {
+			return idx
+		}
+		next := indexOutsideReferences(line[idx+1:], "#"+stop)
+		if next == -1 {
+			return -1
+		}
+		idx += next + 1
+	}
+	return -1
+}
+
+// splitComment splits a comment off the end of a line, returning the line
+// without the comment and the comment's text
+func splitComment(line string) (string, string) {
+	idx := indexComment(line, "")
+	if idx == -1 {
+		return line, ""
+	}
+	return strings.TrimRight(line[:idx], " \t"), commentText(line[idx:])
+}
+
+// splitTrailingComment splits a comment off the end of a line read by the
+// scanner, returning the line without the comment and the comment, which
+// is nil if there is none
+func splitTrailingComment(scanner *MakefileScanner, line, stop string) (string, *Comment) {
+	idx := indexComment(line, stop)
+	if idx == -1 {
+		return line, nil
+	}
+
+	comment := &Comment{
+		Text:       commentText(line[idx:]),
+		FileName:   scanner.FileName,
+		LineNumber: scanner.LineNumber,
+		Range:      scanner.Range(),
+	}
+	// the comment usually starts on the last physical line, which makes it
+	// possible to locate it exactly
+	last := scanner.lines[len(scanner.lines)-1]
+	if tail := len(line) - idx; tail <= len(strings.TrimLeft(last, " \t")) {
+		comment.LineNumber = scanner.LastLine
+		comment.Range.Start = Position{Line: scanner.LastLine, Column: len(last) - tail + 1}
+	}
+	return strings.TrimRight(line[:idx], " \t"), comment
+}
+
+// docFor returns the comments documenting a rule or variable on the given
+// line, which are the ones directly preceding it
+func docFor(doc CommentList, lineNumber int) CommentList {
+	if len(doc) == 0 || doc[len(doc)-1].Range.End.Line != lineNumber-1 {
+		return nil
+	}
+	return doc
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Comments(t *testing.T) {
+	t.Parallel()
+	makefile := `# Makefile for the app
+
+# version of the linter
+# to install
+VERSION := v2 #latest
+HASH := a\#b # the hash
+FILES := $(filter-out #%,$(wildcard *)) # no backups
+
+# build everything
+all: app lib # default target
+	@echo done
+
+.PHONY: all # phony
+quick: ; echo '#' # passed to the shell
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Variables, 3)
+	require.Len(t, ret.Rules, 3)
+
+	version := ret.Variables[0]
+	assert.Equal(t, "v2", version.Assignment)
+	require.NotNil(t, version.TrailingComment)
+	assert.Equal(t, "latest", version.TrailingComment.Text)
+	assert.Equal(t, Position{Line: 5, Column: 15}, version.TrailingComment.Range.Start)
+	require.Len(t, version.Doc, 2)
+	assert.Equal(t, "version of the linter", version.Doc[0].Text)
+	assert.Equal(t, "to install", version.Doc[1].Text)
+
+	assert.Equal(t, `a\#b`, ret.Variables[1].Assignment)
+	assert.Equal(t, "the hash", ret.Variables[1].TrailingComment.Text)
+	assert.Empty(t, ret.Variables[1].Doc)
+
+	assert.Equal(t, "$(filter-out #%,$(wildcard *))", ret.Variables[2].Assignment)
+	assert.Equal(t, "no backups", ret.Variables[2].TrailingComment.Text)
+
+	all := ret.Rules[0]
+	assert.Equal(t, []string{"app", "lib"}, all.Dependencies)
+	assert.Equal(t, "default target", all.TrailingComment.Text)
+	require.Len(t, all.Doc, 1)
+	assert.Equal(t, "build everything", all.Doc[0].Text)
+
+	phony := ret.Rules[1]
+	assert.Equal(t, []string{"all"}, phony.Dependencies)
+	assert.Equal(t, "phony", phony.TrailingComment.Text)
+	assert.Empty(t, phony.Doc)
+
+	quick := ret.Rules[2]
+	assert.Nil(t, quick.TrailingComment)
+	assert.Equal(t, []string{"echo '#' # passed to the shell"}, quick.Body)
+
+	texts := []string{}
+	for _, comment := range ret.Comments {
+		texts = append(texts, comment.Text)
+	}
+	assert.Equal(t, []string{
+		"Makefile for the app", "version of the linter", "to install", "latest",
+		"the hash", "no backups", "build everything", "default target", "phony",
+	}, texts)
+}
+
+func TestIndexComment(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line string
+		stop string
+		idx  int
+	}{
+		{"A = b", "", -1},
+		{"A = b # c", "", 6},
+		{`A = \# b`, "", -1},
+		{`A = \\# b`, "", 6},
+		{`A = \# b # c`, "", 9},
+		{"A = $(shell echo #) # c", "", 20},
+		{"a: ; echo # c", ";", -1},
+		{"a: b # ; c", ";", 5},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.idx, indexComment(test.line, test.stop), test.line)
+	}
+}
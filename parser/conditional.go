@@ -0,0 +1,277 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/checkmake/checkmake/logger"
+)
+
+// Conditional represents a conditional block in a Makefile, starting at an
+// ifeq, ifneq, ifdef or ifndef directive and ending at the matching endif.
+// Every conditional in a Makefile gets a unique ID in the order in which they
+// are opened, which is what rules and variables refer to in their Conditions.
+type Conditional struct {
+	ID            int
+	Branches      []ConditionalBranch
+	FileName      string
+	LineNumber    int
+	EndLineNumber int
+	Range         Range
+}
+
+// ConditionalList represents a list of conditionals
+type ConditionalList []Conditional
+
+// ConditionalBranch represents a single branch of a conditional block. The
+// first branch holds the opening directive, every following branch comes
+// from an else clause. A plain else has an empty Directive and Condition.
+type ConditionalBranch struct {
+	Directive    string
+	Condition    string
+	Rules        RuleList
+	Variables    VariableList
+	Conditionals ConditionalList
+	LineNumber   int
+	// Range spans from the branch's directive up to the next else or endif
+	Range Range
+}
+
+// ConditionalBranchRef points to a single branch of a conditional by the
+// conditional's ID and the index of the branch within it
+type ConditionalBranchRef struct {
+	Conditional int
+	Branch      int
+}
+
+// MutuallyExclusive reports whether two definitions with the given
+// conditional scopes can never be active at the same time, which is the case
+// when they live in different branches of the same conditional.
+func MutuallyExclusive(a, b []ConditionalBranchRef) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].Conditional != b[i].Conditional {
+			return false
+		}
+		if a[i].Branch != b[i].Branch {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// reFindConditional captures the opening directive of a conditional block.
+	// Group 1: The directive (ifeq, ifneq, ifdef, ifndef).
+	// Group 2: The condition, e.g. "($(OS),Windows_NT)" or "DEBUG".
+	reFindConditional = regexp.MustCompile(`^\s*(ifeq|ifneq|ifdef|ifndef)(\s.*|\(.*)$`)
+
+	// reFindElse captures an else clause, optionally followed by another
+	// conditional directive as in "else ifeq (a,b)".
+	// Group 1: The directive of the chained condition, if any.
+	// Group 2: The chained condition, if any.
+	reFindElse = regexp.MustCompile(`^\s*else(?:\s+(ifeq|ifneq|ifdef|ifndef)(\s.*|\(.*))?\s*(?:#.*)?$`)
+
+	// reFindEndif matches the end of a conditional block.
+	reFindEndif = regexp.MustCompile(`^\s*endif\s*(?:#.*)?$`)
+)
+
+// conditionalStack keeps track of the conditionals which are currently open
+// while scanning through a Makefile
+type conditionalStack struct {
+	frames   []Conditional
+	closed   ConditionalList
+	fileName string
+	nextID   int
+	// outer is the scope of the include directive for stacks of included
+	// files, conditionals don't span files but their scope does
+	outer []ConditionalBranchRef
+}
+
+// isConditionalDirective reports whether the line is one of the directives
+// that open, continue or close a conditional block
+func isConditionalDirective(line string) bool {
+	if matches := reFindConditional.FindStringSubmatch(line); matches != nil {
+		condition := strings.TrimSpace(matches[2])
+		// guard against variables that happen to be named like a directive,
+		// e.g. "ifdef = foo"
+		return !strings.HasPrefix(condition, "=") &&
+			!strings.HasPrefix(strings.TrimLeft(condition, ":?+!"), "=")
+	}
+	return reFindElse.MatchString(line) || reFindEndif.MatchString(line)
+}
+
+// handle processes a conditional directive spanning the given range. It
+// returns a parse error for else and endif directives without a matching
+// conditional, which are skipped.
+func (s *conditionalStack) handle(line string, rng Range) *ParseError {
+	if matches := reFindConditional.FindStringSubmatch(line); matches != nil {
+		s.open(matches[1], strings.TrimSpace(matches[2]), rng)
+		return nil
+	}
+
+	if matches := reFindElse.FindStringSubmatch(line); matches != nil {
+		return s.branch("else", matches[1], strings.TrimSpace(matches[2]), line, rng)
+	}
+
+	if reFindEndif.MatchString(line) {
+		return s.end("endif", line, rng)
+	}
+	return nil
+}
+
+// branch starts a new branch of the innermost conditional for an else
+// clause, which is named by keyword in parse errors
+func (s *conditionalStack) branch(keyword, directive, condition, line string, rng Range) *ParseError {
+	if len(s.frames) == 0 {
+		logger.Debug(fmt.Sprintf("Found '%s' without matching conditional on line %d", keyword, rng.Start.Line))
+		return unbalanced(line, rng, fmt.Sprintf("'%s' without matching conditional", keyword))
+	}
+	current := &s.frames[len(s.frames)-1]
+	current.Branches[len(current.Branches)-1].Range.End = rng.Start
+	current.Branches = append(current.Branches, ConditionalBranch{
+		Directive:  directive,
+		Condition:  condition,
+		LineNumber: rng.Start.Line,
+		Range:      Range{FileName: rng.FileName, Start: rng.Start},
+	})
+	return nil
+}
+
+// end closes the innermost conditional for an endif directive, which is
+// named by keyword in parse errors
+func (s *conditionalStack) end(keyword, line string, rng Range) *ParseError {
+	if len(s.frames) == 0 {
+		logger.Debug(fmt.Sprintf("Found '%s' without matching conditional on line %d", keyword, rng.Start.Line))
+		return unbalanced(line, rng, fmt.Sprintf("'%s' without matching conditional", keyword))
+	}
+	s.close(rng)
+	return nil
+}
+
+// unbalanced returns a parse error for a conditional directive on the given
+// line and range
+func unbalanced(line string, rng Range, message string) *ParseError {
+	return &ParseError{
+		Message:    message,
+		Snippet:    line,
+		FileName:   rng.FileName,
+		LineNumber: rng.Start.Line,
+		Range:      rng,
+	}
+}
+
+// open pushes a new conditional onto the stack
+func (s *conditionalStack) open(directive, condition string, rng Range) {
+	s.frames = append(s.frames, Conditional{
+		ID: s.nextID,
+		Branches: []ConditionalBranch{{
+			Directive:  directive,
+			Condition:  condition,
+			LineNumber: rng.Start.Line,
+			Range:      Range{FileName: rng.FileName, Start: rng.Start},
+		}},
+		FileName:   s.fileName,
+		LineNumber: rng.Start.Line,
+		Range:      Range{FileName: rng.FileName, Start: rng.Start},
+	})
+	s.nextID++
+}
+
+// close pops the innermost conditional off the stack and attaches it to its
+// parent branch or the list of top level conditionals. The passed in range
+// is the one of the endif directive.
+func (s *conditionalStack) close(rng Range) {
+	top := s.frames[len(s.frames)-1]
+	s.frames = s.frames[:len(s.frames)-1]
+	top.EndLineNumber = rng.End.Line
+	top.Range.End = rng.End
+	top.Branches[len(top.Branches)-1].Range.End = rng.Start
+
+	s.adopt(ConditionalList{top})
+}
+
+// adopt attaches finished conditionals to the current branch or the list of
+// top level conditionals if no conditional is open
+func (s *conditionalStack) adopt(conditionals ConditionalList) {
+	if len(s.frames) == 0 {
+		s.closed = append(s.closed, conditionals...)
+		return
+	}
+	parent := &s.frames[len(s.frames)-1]
+	branch := &parent.Branches[len(parent.Branches)-1]
+	branch.Conditionals = append(branch.Conditionals, conditionals...)
+}
+
+// scope returns the chain of conditional branches, outermost first, that
+// are currently active
+func (s *conditionalStack) scope() []ConditionalBranchRef {
+	if len(s.outer)+len(s.frames) == 0 {
+		return nil
+	}
+	ret := make([]ConditionalBranchRef, 0, len(s.outer)+len(s.frames))
+	ret = append(ret, s.outer...)
+	for _, frame := range s.frames {
+		ret = append(ret, ConditionalBranchRef{
+			Conditional: frame.ID,
+			Branch:      len(frame.Branches) - 1,
+		})
+	}
+	return ret
+}
+
+// finish closes all conditionals left open at the end of the file and
+// returns a parse error for each of them
+func (s *conditionalStack) finish(end Position) ParseErrorList {
+	var ret ParseErrorList
+	for len(s.frames) > 0 {
+		top := s.frames[len(s.frames)-1]
+		logger.Debug(fmt.Sprintf("Conditional opened on line %d is never closed", top.LineNumber))
+		opening := top.Branches[0]
+		// BSD conditionals like .if are closed by .endif
+		endif := "endif"
+		if strings.HasPrefix(opening.Directive, ".") {
+			endif = ".endif"
+		}
+		ret = append(ret, ParseError{
+			Message:    fmt.Sprintf("'%s' is never closed by '%s'", opening.Directive, endif),
+			Snippet:    strings.TrimSpace(opening.Directive + " " + opening.Condition),
+			FileName:   s.fileName,
+			LineNumber: top.LineNumber,
+			Range:      Range{FileName: s.fileName, Start: top.Range.Start, End: top.Range.Start},
+		})
+		s.close(Range{FileName: s.fileName, Start: end, End: end})
+	}
+	return ret
+}
+
+// attachToConditionals fills the Rules and Variables of every conditional
+// branch with the rules and variables defined directly within it
+func attachToConditionals(conditionals ConditionalList, ruleList RuleList, variables VariableList) {
+	for i := range conditionals {
+		for b := range conditionals[i].Branches {
+			branch := &conditionals[i].Branches[b]
+			ref := ConditionalBranchRef{Conditional: conditionals[i].ID, Branch: b}
+			for _, rule := range ruleList {
+				if innermostBranch(rule.Conditions) == ref {
+					branch.Rules = append(branch.Rules, rule)
+				}
+			}
+			for _, variable := range variables {
+				if innermostBranch(variable.Conditions) == ref {
+					branch.Variables = append(branch.Variables, variable)
+				}
+			}
+			attachToConditionals(branch.Conditionals, ruleList, variables)
+		}
+	}
+}
+
+// innermostBranch returns the innermost branch of a conditional scope or an
+// invalid reference if the scope is empty
+func innermostBranch(scope []ConditionalBranchRef) ConditionalBranchRef {
+	if len(scope) == 0 {
+		return ConditionalBranchRef{Conditional: -1, Branch: -1}
+	}
+	return scope[len(scope)-1]
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"io"
+	"strings"
+)
+
+// CSTKind classifies the nodes of a concrete syntax tree
+type CSTKind int
+
+const (
+	// CSTBlank is an empty or whitespace-only line
+	CSTBlank CSTKind = iota
+	// CSTComment is a comment on a line of its own
+	CSTComment
+	// CSTRule is the line of a rule with its targets and prerequisites.
+	// Its children hold the recipe.
+	CSTRule
+	// CSTRecipe is a line of a recipe
+	CSTRecipe
+	// CSTVariable is a variable assignment on a single logical line
+	CSTVariable
+	// CSTDefine is the line starting a define block. Its children hold the
+	// lines of the value and the closing endef.
+	CSTDefine
+	// CSTDefineBody is a line of the value of a define block
+	CSTDefineBody
+	// CSTEndef is the endef line closing a define block
+	CSTEndef
+	// CSTConditional is a conditional directive like ifeq, else or endif
+	CSTConditional
+	// CSTInclude is an include directive
+	CSTInclude
+	// CSTDirective is any other directive like export or vpath
+	CSTDirective
+	// CSTExpansion is a line consisting of a single variable reference or
+	// function call like "$(info building)"
+	CSTExpansion
+	// CSTUnknown is a line the parser could not make sense of
+	CSTUnknown
+)
+
+// String returns the name of the node kind
+func (k CSTKind) String() string {
+	switch k {
+	case CSTComment:
+		return "comment"
+	case CSTRule:
+		return "rule"
+	case CSTRecipe:
+		return "recipe"
+	case CSTVariable:
+		return "variable"
+	case CSTDefine:
+		return "define"
+	case CSTDefineBody:
+		return "define-body"
+	case CSTEndef:
+		return "endef"
+	case CSTConditional:
+		return "conditional"
+	case CSTInclude:
+		return "include"
+	case CSTDirective:
+		return "directive"
+	case CSTExpansion:
+		return "expansion"
+	case CSTUnknown:
+		return "unknown"
+	}
+	return "blank"
+}
+
+// CST is the lossless concrete syntax tree of a Makefile. Every byte of the
+// input, including whitespace, comments, line continuations and line
+// terminators, is owned by exactly one node, so printing the tree with
+// Print reproduces the input exactly.
+type CST struct {
+	FileName string
+	// Nodes holds the top level nodes in the order they appear in
+	Nodes []*CSTNode
+}
+
+// CSTNode is a single logical line of a Makefile, which spans multiple
+// physical lines if they are continued with a backslash, along with the
+// lines belonging to it like the recipe of a rule
+type CSTNode struct {
+	Kind CSTKind
+	// Text is the source text of the logical line including its line
+	// terminators
+	Text string
+	// Offset is the byte offset of Text in the file
+	Offset int
+	// Range spans the physical lines of the node from the first column
+	Range Range
+	// Children holds the nodes belonging to this one. For rules these are
+	// the recipe lines and everything in between them, like comments or
+	// conditional directives. For define blocks these are the lines of the
+	// value and the endef line.
+	Children []*CSTNode
+
+	// owner is the rule or define node this node belongs to
+	owner *CSTNode
+}
+
+// ParseCST parses the Makefile read from r into a concrete syntax tree. The
+// name is used as the file name in the ranges of the nodes.
+func ParseCST(name string, r io.Reader) (*CST, error) {
+	makefile, err := ParseReader(name, r)
+	return makefile.CST, err
+}
+
+// Print returns the source text of the concrete syntax tree, which is
+// exactly the input it was parsed from
+func Print(cst *CST) string {
+	var b strings.Builder
+	var print func(nodes []*CSTNode)
+	print = func(nodes []*CSTNode) {
+		for _, node := range nodes {
+			b.WriteString(node.Text)
+			print(node.Children)
+		}
+	}
+	print(cst.Nodes)
+	return b.String()
+}
+
+// NodeAt returns the node starting on the given line, which may be the
+// child of another node, or nil if there is none
+func (t *CST) NodeAt(line int) *CSTNode {
+	var find func(nodes []*CSTNode) *CSTNode
+	find = func(nodes []*CSTNode) *CSTNode {
+		for _, node := range nodes {
+			if node.Range.Start.Line == line {
+				return node
+			}
+			if node.Range.Start.Line < line && len(node.Children) > 0 {
+				if found := find(node.Children); found != nil {
+					return found
+				}
+			}
+		}
+		return nil
+	}
+	return find(t.Nodes)
+}
+
+// newCST splits the Makefile read from r into logical lines, which end up as
+// the flat list of nodes of the returned tree. The kinds of the nodes are
+// set while parsing them, after which nest arranges them into a tree.
+func newCST(name string, r io.Reader) (*CST, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &CST{FileName: name}
+	text := string(data)
+	offset, line := 0, 1
+	for offset < len(text) {
+		node := &CSTNode{
+			Kind:   CSTBlank,
+			Offset: offset,
+			Range:  Range{FileName: name, Start: Position{Line: line, Column: 1}},
+		}
+		end := offset
+		for end < len(text) {
+			physical := physicalLine(text[end:])
+			content := trimLineTerminator(physical)
+			end += len(physical)
+			node.Range.End = Position{Line: line, Column: len(content) + 1}
+			line++
+			if !isContinued(content) {
+				break
+			}
+		}
+		node.Text = text[offset:end]
+		ret.Nodes = append(ret.Nodes, node)
+		offset = end
+	}
+	return ret, nil
+}
+
+// nest moves the nodes belonging to a rule or a define block into its
+// children, along with everything in between them
+func (t *CST) nest() {
+	last := map[*CSTNode]int{}
+	for i, node := range t.Nodes {
+		if node.owner != nil {
+			last[node.owner] = i
+		}
+	}
+
+	nodes := t.Nodes
+	t.Nodes = nil
+	for i := 0; i < len(nodes); i++ {
+		node := nodes[i]
+		if end, ok := last[node]; ok {
+			node.Children = nodes[i+1 : end+1]
+			i = end
+		}
+		t.Nodes = append(t.Nodes, node)
+	}
+}
+
+// physicalLine returns the first physical line of text including its line
+// terminator, if any
+func physicalLine(text string) string {
+	if idx := strings.IndexByte(text, '\n'); idx != -1 {
+		return text[:idx+1]
+	}
+	return text
+}
+
+// trimLineTerminator removes the line terminator, "\n" or "\r\n", from a
+// physical line like bufio.ScanLines does
+func trimLineTerminator(line string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+}
+
+// lines returns the physical lines of the node without line terminators
+func (n *CSTNode) lines() []string {
+	var ret []string
+	for text := n.Text; text != ""; {
+		physical := physicalLine(text)
+		ret = append(ret, trimLineTerminator(physical))
+		text = text[len(physical):]
+	}
+	return ret
+}
@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCST_PrintRoundTripsFixtures(t *testing.T) {
+	files, err := filepath.Glob("../fixtures/*.make")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		cst, _ := ParseCST(file, strings.NewReader(string(data)))
+		require.NotNil(t, cst, file)
+		assert.Equal(t, string(data), Print(cst), file)
+	}
+}
+
+func TestCST_PrintRoundTripsRawInput(t *testing.T) {
+	t.Parallel()
+	inputs := map[string]string{
+		"empty":                 "",
+		"no trailing newline":   "all:\n\techo done",
+		"crlf":                  "A = 1\r\nall: \\\r\n  one\r\n\techo $(A)\r\n",
+		"blank lines":           "\n  \n\t\nA = 1\n\n",
+		"trailing backslash":    "A = 1 \\",
+		"define":                "define X =\n  one\n\n  two\nendef # done\nall:\n",
+		"unknown":               "this is no make\nall:\n    spaces\n",
+		"conditional in recipe": "all:\n\techo a\nifdef DEBUG\n\techo b\nelse\n\techo c\nendif\n# tail\n",
+	}
+	for name, input := range inputs {
+		cst, _ := ParseCST("Makefile", strings.NewReader(input))
+		require.NotNil(t, cst, name)
+		assert.Equal(t, input, Print(cst), name)
+	}
+}
+
+func TestCST_Kinds(t *testing.T) {
+	t.Parallel()
+	cst, err := ParseCST("Makefile", strings.NewReader(`# comment
+SRCS = a.c \
+       b.c
+include common.mk
+export SRCS
+
+define BODY
+  text
+endef
+$(info loading)
+all: $(SRCS)
+	cc -o $@ \
+	  $^
+ifdef DEBUG
+	strip $@
+endif
+
+.PHONY: all
+`))
+	require.NoError(t, err)
+
+	var kinds []string
+	for _, node := range cst.Nodes {
+		kinds = append(kinds, node.Kind.String())
+	}
+	assert.Equal(t, []string{
+		"comment", "variable", "include", "directive", "blank", "define",
+		"expansion", "rule", "conditional", "blank", "rule",
+	}, kinds)
+
+	srcs := cst.Nodes[1]
+	assert.Equal(t, "SRCS = a.c \\\n       b.c\n", srcs.Text)
+	assert.Equal(t, 10, srcs.Offset)
+	assert.Equal(t, Position{Line: 2, Column: 1}, srcs.Range.Start)
+	assert.Equal(t, Position{Line: 3, Column: 11}, srcs.Range.End)
+
+	define := cst.Nodes[5]
+	require.Len(t, define.Children, 2)
+	assert.Equal(t, CSTDefineBody, define.Children[0].Kind)
+	assert.Equal(t, CSTEndef, define.Children[1].Kind)
+
+	rule := cst.Nodes[7]
+	var children []CSTKind
+	for _, child := range rule.Children {
+		children = append(children, child.Kind)
+	}
+	// the endif after the last recipe line isn't part of the rule
+	assert.Equal(t, []CSTKind{CSTRecipe, CSTConditional, CSTRecipe}, children)
+	assert.Equal(t, "\tcc -o $@ \\\n\t  $^\n", rule.Children[0].Text)
+}
+
+func TestCST_UnknownLines(t *testing.T) {
+	t.Parallel()
+	makefile, err := ParseReader("Makefile", strings.NewReader("A = 1\nthis is no make\n"))
+	require.NoError(t, err)
+	require.Len(t, makefile.CST.Nodes, 2)
+	assert.Equal(t, CSTUnknown, makefile.CST.Nodes[1].Kind)
+	assert.Len(t, makefile.Errors, 1)
+}
+
+func TestCST_NodeAt(t *testing.T) {
+	t.Parallel()
+	cst, err := ParseCST("Makefile", strings.NewReader("A = 1\nall:\n\techo a \\\n\t  b\n\techo c\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, CSTVariable, cst.NodeAt(1).Kind)
+	assert.Equal(t, CSTRule, cst.NodeAt(2).Kind)
+	assert.Equal(t, "\techo a \\\n\t  b\n", cst.NodeAt(3).Text)
+	assert.Nil(t, cst.NodeAt(4), "continuation lines don't start a node")
+	assert.Equal(t, "\techo c\n", cst.NodeAt(5).Text)
+	assert.Nil(t, cst.NodeAt(6))
+}
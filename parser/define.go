@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/checkmake/checkmake/logger"
+)
+
+var (
+	// reFindDefine captures the start of a multi-line variable definition.
+	// Group 1: Modifiers like override or export, if any.
+	// Group 2: The variable name.
+	// Group 3: The assignment operator, if any. Defaults to '='.
+	reFindDefine = regexp.MustCompile(`^\s*((?:(?:override|export|private)\s+)*)define\s+([^\s:?+!=#]+)\s*(=|:{1,3}=|[?+!]=)?\s*(?:#.*)?$`)
+
+	// reFindEndef matches the end of a multi-line variable definition.
+	reFindEndef = regexp.MustCompile(`^\s*endef\s*(?:#.*)?$`)
+)
+
+// isDefine reports whether the line starts a define block
+func isDefine(line string) bool {
+	return reFindDefine.MatchString(line)
+}
+
+// parseDefine parses a define ... endef block into a single Variable. The
+// scanner has to reside on the define line and is left on the first line
+// after the matching endef. Nested define blocks are part of the value. If
+// the block is never terminated, the variable spans the rest of the file
+// and a parse error is returned alongside.
+func parseDefine(scanner *MakefileScanner) (Variable, *ParseError) {
+	defineErr := newParseError(scanner, "")
+	matches := reFindDefine.FindStringSubmatch(scanner.Text())
+
+	op := matches[3]
+	if op == "" {
+		op = "="
+	}
+	modifiers := strings.Fields(matches[1])
+	ret := Variable{
+		Name:           matches[2],
+		Override:       slices.Contains(modifiers, "override"),
+		Exported:       slices.Contains(modifiers, "export"),
+		Private:        slices.Contains(modifiers, "private"),
+		Define:         true,
+		Operator:       op,
+		SimplyExpanded: isSimplyExpanded(op),
+		FileName:       scanner.FileName,
+		LineNumber:     scanner.LineNumber,
+		Range:          scanner.Range(),
+	}
+
+	scanner.mark(CSTDefine, nil)
+	defineNode := scanner.node
+
+	lines := []string{}
+	depth := 0
+	terminated := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		scanner.mark(CSTDefineBody, defineNode)
+		if reFindEndef.MatchString(line) {
+			if depth == 0 {
+				scanner.mark(CSTEndef, defineNode)
+				terminated = true
+				ret.Range.End = scanner.Range().End
+				scanner.Scan()
+				break
+			}
+			depth--
+		} else if reFindDefine.MatchString(line) {
+			depth++
+		}
+		lines = append(lines, scanner.RawText())
+		ret.Range.End = scanner.Range().End
+	}
+
+	ret.Assignment = strings.Join(lines, "\n")
+	ret.Expression = ParseExpression(ret.Assignment)
+	if !terminated {
+		logger.Debug(fmt.Sprintf("define of %q on line %d is never terminated by endef", ret.Name, ret.LineNumber))
+		defineErr.Message = fmt.Sprintf("'define' of %q is never terminated by 'endef'", ret.Name)
+		return ret, defineErr
+	}
+	return ret, nil
+}
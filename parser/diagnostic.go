@@ -0,0 +1,18 @@
+package parser
+
+// Diagnostic describes a line the parser could not make sense of, which
+// make would most likely reject, like a typo or a recipe line indented with
+// spaces instead of a tab
+type Diagnostic struct {
+	// Reason tells why the line could not be parsed, e.g. "recipe line
+	// indented with spaces"
+	Reason string
+	// Text is the line as it was read
+	Text       string
+	FileName   string
+	LineNumber int
+	Range      Range
+}
+
+// DiagnosticList represents a list of diagnostics
+type DiagnosticList []Diagnostic
@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect selects the flavor of make a Makefile is written for
+type Dialect int
+
+const (
+	// GNU is GNU make, which is the default
+	GNU Dialect = iota
+	// POSIX is make as specified by POSIX. It is parsed like GNU make,
+	// which is a superset of it.
+	POSIX
+	// BSD is the make of the BSDs, also known as bmake, which adds
+	// directives like ".if" and ".include <file>"
+	BSD
+)
+
+// String returns the name of the dialect
+func (d Dialect) String() string {
+	switch d {
+	case POSIX:
+		return "posix"
+	case BSD:
+		return "bsd"
+	}
+	return "gnu"
+}
+
+// ParseDialect returns the dialect with the given name, which is one of
+// gnu, posix and bsd
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "gnu":
+		return GNU, nil
+	case "posix":
+		return POSIX, nil
+	case "bsd":
+		return BSD, nil
+	}
+	return GNU, fmt.Errorf("invalid dialect: %q (supported: gnu, posix, bsd)", name)
+}
@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Export represents a standalone export or unexport directive, which
+// doesn't assign a value, like "export GOPATH" or a bare "unexport"
+type Export struct {
+	Directive string
+	// Names holds the variables the directive applies to. It is empty for
+	// a bare export or unexport, which applies to all variables.
+	Names      []string
+	FileName   string
+	LineNumber int
+	Range      Range
+	Conditions []ConditionalBranchRef
+}
+
+// ExportList represents a list of export directives
+type ExportList []Export
+
+// Undefine represents an undefine directive, which removes variables like
+// "undefine DEBUG"
+type Undefine struct {
+	// Names holds the variables which are undefined
+	Names []string
+	// Override is set for "override undefine", which also removes
+	// variables set on the command line
+	Override   bool
+	FileName   string
+	LineNumber int
+	Range      Range
+	Conditions []ConditionalBranchRef
+}
+
+// UndefineList represents a list of undefine directives
+type UndefineList []Undefine
+
+// VPath represents a vpath directive or an assignment to the VPATH variable,
+// which tell make where to search for prerequisites
+type VPath struct {
+	// Directive is "vpath" for the directive and "VPATH" for the variable
+	Directive string
+	// Pattern is the pattern of the files the search path applies to, it is
+	// empty for VPATH and a bare vpath
+	Pattern string
+	// Directories holds the directories to search. A vpath directive
+	// without directories clears the search path for the pattern.
+	Directories []string
+	FileName    string
+	LineNumber  int
+	Range       Range
+	Conditions  []ConditionalBranchRef
+}
+
+// VPathList represents a list of vpath directives
+type VPathList []VPath
+
+var (
+	// reFindExport captures export and unexport directives without an
+	// assignment.
+	// Group 1: The directive (export or unexport).
+	// Group 2: The space separated list of variable names, if any.
+	reFindExport = regexp.MustCompile(`^\s*(export|unexport)(?:\s+([^=:]*))?$`)
+
+	// reFindUndefine captures undefine directives.
+	// Group 1: "override " if the directive is prefixed with override.
+	// Group 2: The space separated list of variable names.
+	reFindUndefine = regexp.MustCompile(`^\s*(override\s+)?undefine\s+([^=:\s][^=:]*)$`)
+
+	// reFindVPath captures vpath directives.
+	// Group 1: The pattern, if any.
+	// Group 2: The directories, if any.
+	reFindVPath = regexp.MustCompile(`^\s*vpath(?:\s+([^\s=:]\S*)(?:\s+(.*))?)?\s*$`)
+)
+
+// isExport reports whether the line is a standalone export or unexport
+// directive
+func isExport(line string) bool {
+	line, _ = splitComment(line)
+	return reFindExport.MatchString(line)
+}
+
+// parseExport parses the export or unexport directive the scanner resides on
+func parseExport(scanner *MakefileScanner) Export {
+	line, _ := splitComment(scanner.Text())
+	matches := reFindExport.FindStringSubmatch(line)
+	return Export{
+		Directive:  matches[1],
+		Names:      strings.Fields(matches[2]),
+		FileName:   scanner.FileName,
+		LineNumber: scanner.LineNumber,
+		Range:      scanner.Range(),
+	}
+}
+
+// isUndefine reports whether the line is an undefine directive
+func isUndefine(line string) bool {
+	line, _ = splitComment(line)
+	return reFindUndefine.MatchString(strings.TrimSpace(line))
+}
+
+// parseUndefine parses the undefine directive the scanner resides on
+func parseUndefine(scanner *MakefileScanner) Undefine {
+	line, _ := splitComment(scanner.Text())
+	matches := reFindUndefine.FindStringSubmatch(strings.TrimSpace(line))
+	return Undefine{
+		Names:      strings.Fields(matches[2]),
+		Override:   matches[1] != "",
+		FileName:   scanner.FileName,
+		LineNumber: scanner.LineNumber,
+		Range:      scanner.Range(),
+	}
+}
+
+// isVPath reports whether the line is a vpath directive
+func isVPath(line string) bool {
+	line, _ = splitComment(line)
+	return reFindVPath.MatchString(line)
+}
+
+// parseVPath parses the vpath directive the scanner resides on
+func parseVPath(scanner *MakefileScanner) VPath {
+	line, _ := splitComment(scanner.Text())
+	matches := reFindVPath.FindStringSubmatch(line)
+	return VPath{
+		Directive:   "vpath",
+		Pattern:     matches[1],
+		Directories: splitSearchPath(matches[2]),
+		FileName:    scanner.FileName,
+		LineNumber:  scanner.LineNumber,
+		Range:       scanner.Range(),
+	}
+}
+
+// vpathFromVariable returns the search path set by an assignment to VPATH
+func vpathFromVariable(variable Variable) VPath {
+	return VPath{
+		Directive:   "VPATH",
+		Directories: splitSearchPath(variable.Assignment),
+		FileName:    variable.FileName,
+		LineNumber:  variable.LineNumber,
+		Range:       variable.Range,
+		Conditions:  variable.Conditions,
+	}
+}
+
+// splitSearchPath splits a list of directories separated by colons or
+// whitespace
+func splitSearchPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r == ':' || r == ' ' || r == '\t'
+	})
+}
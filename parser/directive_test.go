@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_VariableModifiers(t *testing.T) {
+	t.Parallel()
+	makefile := `export GO111MODULE = on
+override CFLAGS += -O2
+private export BUILD_TIME := $(shell date)
+export override LDFLAGS ?= -s
+override define BANNER
+hello
+endef
+export = not a modifier
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Variables, 6)
+	assert.Empty(t, ret.Exports)
+
+	tests := []struct {
+		name     string
+		operator string
+		value    string
+		override bool
+		exported bool
+		private  bool
+	}{
+		{"GO111MODULE", "=", "on", false, true, false},
+		{"CFLAGS", "+=", "-O2", true, false, false},
+		{"BUILD_TIME", ":=", "$(shell date)", false, true, true},
+		{"LDFLAGS", "?=", "-s", true, true, false},
+		{"BANNER", "=", "hello", true, false, false},
+		{"export", "=", "not a modifier", false, false, false},
+	}
+	for i, test := range tests {
+		variable := ret.Variables[i]
+		assert.Equal(t, test.name, variable.Name)
+		assert.Equal(t, test.operator, variable.Operator, test.name)
+		assert.Equal(t, test.value, variable.Assignment, test.name)
+		assert.Equal(t, test.override, variable.Override, test.name)
+		assert.Equal(t, test.exported, variable.Exported, test.name)
+		assert.Equal(t, test.private, variable.Private, test.name)
+	}
+}
+
+func TestParse_ComputedVariableNames(t *testing.T) {
+	t.Parallel()
+	makefile := `$(ARCH)_FLAGS := -m64
+override $(X)_CFLAGS += -O2
+export ${PREFIX}_HOME = /opt
+$($(ARCH)_NAME)_DIR ?= build
+debug: $(ARCH)_FLAGS += -g
+$(info a=b)
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	assert.Empty(t, ret.Diagnostics)
+	require.Len(t, ret.Variables, 4)
+
+	tests := []struct {
+		name     string
+		operator string
+		value    string
+	}{
+		{"$(ARCH)_FLAGS", ":=", "-m64"},
+		{"$(X)_CFLAGS", "+=", "-O2"},
+		{"${PREFIX}_HOME", "=", "/opt"},
+		{"$($(ARCH)_NAME)_DIR", "?=", "build"},
+	}
+	for i, test := range tests {
+		variable := ret.Variables[i]
+		assert.Equal(t, test.name, variable.Name)
+		assert.Equal(t, test.operator, variable.Operator, test.name)
+		assert.Equal(t, test.value, variable.Assignment, test.name)
+	}
+	assert.True(t, ret.Variables[1].Override)
+	assert.True(t, ret.Variables[2].Exported)
+
+	require.Len(t, ret.TargetVariables, 1)
+	assert.Equal(t, "$(ARCH)_FLAGS", ret.TargetVariables[0].Name)
+	assert.Equal(t, []string{"debug"}, ret.TargetVariables[0].Targets)
+}
+
+func TestParse_ExportDirectives(t *testing.T) {
+	t.Parallel()
+	makefile := `export
+export GOPATH GOFLAGS # for go
+unexport MAKEFLAGS
+unexport
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	assert.Empty(t, ret.Variables)
+	assert.Empty(t, ret.Rules)
+	require.Len(t, ret.Exports, 4)
+
+	assert.Equal(t, "export", ret.Exports[0].Directive)
+	assert.Empty(t, ret.Exports[0].Names)
+	assert.Equal(t, "export", ret.Exports[1].Directive)
+	assert.Equal(t, []string{"GOPATH", "GOFLAGS"}, ret.Exports[1].Names)
+	assert.Equal(t, 2, ret.Exports[1].LineNumber)
+	assert.Equal(t, "unexport", ret.Exports[2].Directive)
+	assert.Equal(t, []string{"MAKEFLAGS"}, ret.Exports[2].Names)
+	assert.Equal(t, "unexport", ret.Exports[3].Directive)
+	assert.Empty(t, ret.Exports[3].Names)
+}
+
+func TestParse_Undefine(t *testing.T) {
+	t.Parallel()
+	makefile := `undefine DEBUG
+override undefine CFLAGS LDFLAGS # reset
+undefine = not a directive
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	assert.Empty(t, ret.Diagnostics)
+	require.Len(t, ret.Undefines, 2)
+
+	assert.Equal(t, []string{"DEBUG"}, ret.Undefines[0].Names)
+	assert.False(t, ret.Undefines[0].Override)
+	assert.Equal(t, []string{"CFLAGS", "LDFLAGS"}, ret.Undefines[1].Names)
+	assert.True(t, ret.Undefines[1].Override)
+	assert.Equal(t, 2, ret.Undefines[1].LineNumber)
+
+	require.Len(t, ret.Variables, 1)
+	assert.Equal(t, "undefine", ret.Variables[0].Name)
+}
+
+func TestParse_VPath(t *testing.T) {
+	t.Parallel()
+	makefile := `VPATH = src:../headers
+vpath %.c src lib
+vpath %.h ../headers:include
+vpath %.o
+vpath
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Variables, 1)
+	assert.Equal(t, "VPATH", ret.Variables[0].Name)
+	assert.Empty(t, ret.Rules)
+	require.Len(t, ret.VPaths, 5)
+
+	assert.Equal(t, VPath{
+		Directive:   "VPATH",
+		Directories: []string{"src", "../headers"},
+		FileName:    "Makefile",
+		LineNumber:  1,
+		Range:       ret.Variables[0].Range,
+	}, ret.VPaths[0])
+
+	assert.Equal(t, "vpath", ret.VPaths[1].Directive)
+	assert.Equal(t, "%.c", ret.VPaths[1].Pattern)
+	assert.Equal(t, []string{"src", "lib"}, ret.VPaths[1].Directories)
+	assert.Equal(t, []string{"../headers", "include"}, ret.VPaths[2].Directories)
+	assert.Equal(t, "%.o", ret.VPaths[3].Pattern)
+	assert.Empty(t, ret.VPaths[3].Directories)
+	assert.Empty(t, ret.VPaths[4].Pattern)
+	assert.Equal(t, 5, ret.VPaths[4].LineNumber)
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError describes a malformed construct make would reject, like a line
+// which is neither a rule nor a variable or an endif without a matching
+// conditional. The parser records it and carries on with the next line.
+type ParseError struct {
+	Message string
+	// Snippet is the offending line as it is in the file
+	Snippet    string
+	FileName   string
+	LineNumber int
+	Range      Range
+}
+
+// Error returns the position and the message of the error
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%s: %s", e.FileName, e.Range.Start, e.Message)
+}
+
+// ParseErrorList represents a list of parse errors. It is returned as the
+// error of parsing in strict mode.
+type ParseErrorList []ParseError
+
+// Error returns the errors of the list, one per line
+func (l ParseErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i := range l {
+		messages[i] = l[i].Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// newParseError returns a parse error for the line the scanner resides on
+func newParseError(scanner *MakefileScanner, message string) *ParseError {
+	return &ParseError{
+		Message:    message,
+		Snippet:    scanner.RawText(),
+		FileName:   scanner.FileName,
+		LineNumber: scanner.LineNumber,
+		Range:      scanner.Range(),
+	}
+}
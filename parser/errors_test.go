@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const malformedMakefile = `all: build
+	@echo all
+endif
+thisisnotarule
+ifeq ($(OS),Windows_NT)
+EXT := .exe
+build:
+	go build
+define TEMPLATE
+$(1): ; @echo $(1)
+`
+
+func TestParse_ErrorRecovery(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseReader("Makefile", strings.NewReader(malformedMakefile))
+	require.NoError(t, err, "parse errors are only returned in strict mode")
+
+	// parsing carries on after every malformed construct
+	require.Len(t, ret.Rules, 2)
+	assert.Equal(t, "build", ret.Rules[1].Target)
+	require.Len(t, ret.Variables, 2)
+	assert.Equal(t, "TEMPLATE", ret.Variables[1].Name)
+	require.Len(t, ret.Conditionals, 1)
+
+	require.Len(t, ret.Errors, 4)
+	assert.Equal(t, ParseError{
+		Message:    "'endif' without matching conditional",
+		Snippet:    "endif",
+		FileName:   "Makefile",
+		LineNumber: 3,
+		Range:      Range{FileName: "Makefile", Start: Position{3, 1}, End: Position{3, 6}},
+	}, ret.Errors[0])
+	assert.Equal(t, "neither a rule nor a variable", ret.Errors[1].Message)
+	assert.Equal(t, "thisisnotarule", ret.Errors[1].Snippet)
+	assert.Equal(t, 4, ret.Errors[1].LineNumber)
+	assert.Equal(t, `'define' of "TEMPLATE" is never terminated by 'endef'`, ret.Errors[2].Message)
+	assert.Equal(t, "define TEMPLATE", ret.Errors[2].Snippet)
+	assert.Equal(t, 9, ret.Errors[2].LineNumber)
+	assert.Equal(t, "'ifeq' is never closed by 'endif'", ret.Errors[3].Message)
+	assert.Equal(t, "ifeq ($(OS),Windows_NT)", ret.Errors[3].Snippet)
+	assert.Equal(t, 5, ret.Errors[3].LineNumber)
+
+	assert.Equal(t, "Makefile:4:1: neither a rule nor a variable", ret.Errors[1].Error())
+}
+
+func TestParse_Strict(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseReaderWithOptions("Makefile", strings.NewReader(malformedMakefile), ParseOptions{Strict: true})
+	require.Error(t, err)
+
+	var parseErrors ParseErrorList
+	require.True(t, errors.As(err, &parseErrors))
+	assert.Equal(t, ret.Errors, parseErrors)
+	assert.Len(t, ret.Rules, 2, "the Makefile is parsed completely in strict mode")
+	assert.Equal(t, "Makefile:3:1: 'endif' without matching conditional\nMakefile:4:1: neither a rule nor a variable\n"+
+		"Makefile:9:1: 'define' of \"TEMPLATE\" is never terminated by 'endef'\nMakefile:5:1: 'ifeq' is never closed by 'endif'", err.Error())
+
+	_, err = ParseReaderWithOptions("Makefile", strings.NewReader("all:\n\t@echo all\n"), ParseOptions{Strict: true})
+	assert.NoError(t, err)
+}
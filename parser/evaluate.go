@@ -0,0 +1,565 @@
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EvalOptions holds the options for evaluating the variables of a Makefile
+type EvalOptions struct {
+	// Overrides holds variables set on the command line of make, like
+	// "make PREFIX=/opt". They take precedence over assignments in the
+	// Makefile which don't use the override directive.
+	Overrides map[string]string
+	// Environment holds variables inherited from the environment, which
+	// assignments in the Makefile take precedence over
+	Environment map[string]string
+}
+
+// Variable origins as reported by the origin function of make
+const (
+	originEnvironment = "environment"
+	originCommandLine = "command line"
+	originFile        = "file"
+	originOverride    = "override"
+)
+
+// Evaluator computes the static values of the global variables of a
+// Makefile. Assignments are applied in the order they appear in, honoring
+// the flavor of every operator. All branches of conditionals are applied
+// alike, so the last assignment of a variable wins. Whatever can't be
+// known without running make, like calls of $(shell) or $(wildcard) and
+// references to undefined or automatic variables, is kept as it is written
+// in the expanded values and makes them non-static.
+//
+// BSD make has no flavors of variables, ":=" expands the value right away
+// but keeps references to undefined variables and "$$" as they are, so they
+// are expanded whenever the variable is.
+type Evaluator struct {
+	dialect   Dialect
+	variables map[string]*evalVariable
+}
+
+// evalVariable holds the state of a variable during evaluation
+type evalVariable struct {
+	origin string
+	simple bool
+	// value is the expanded value of a simply expanded variable
+	value string
+	// static reports whether value is fully known
+	static bool
+	// expr is the unexpanded value of a recursively expanded variable
+	expr Expression
+}
+
+// NewEvaluator evaluates the global variable assignments of a Makefile
+func NewEvaluator(makefile Makefile, opts EvalOptions) *Evaluator {
+	e := &Evaluator{dialect: makefile.Dialect, variables: map[string]*evalVariable{}}
+	for name, value := range opts.Environment {
+		e.variables[name] = &evalVariable{origin: originEnvironment, expr: ParseExpression(value)}
+	}
+	for name, value := range opts.Overrides {
+		e.variables[name] = &evalVariable{origin: originCommandLine, expr: ParseExpression(value)}
+	}
+	for _, variable := range makefile.Variables {
+		e.assign(variable)
+	}
+	return e
+}
+
+// assign applies a single variable assignment
+func (e *Evaluator) assign(variable Variable) {
+	old := e.variables[variable.Name]
+	if old != nil && (old.origin == originCommandLine || old.origin == originOverride) && !variable.Override {
+		return
+	}
+	origin := originFile
+	if variable.Override {
+		origin = originOverride
+	}
+
+	switch variable.Operator {
+	case "?=":
+		if old != nil {
+			return
+		}
+	case "+=":
+		if old == nil {
+			break
+		}
+		old.origin = origin
+		if old.simple {
+			value, static := e.Expand(variable.Expression)
+			old.value = joinWords(old.value, value)
+			old.static = old.static && static
+		} else {
+			old.expr = ParseExpression(joinWords(old.expr.String(), variable.Expression.String()))
+		}
+		return
+	case ":=", "::=", ":::=":
+		if e.dialect == BSD {
+			x := expander{evaluator: e, visiting: map[string]bool{}, keepDollars: true}
+			value, _ := x.expand(variable.Expression)
+			e.variables[variable.Name] = &evalVariable{origin: origin, expr: ParseExpression(value)}
+			return
+		}
+		value, static := e.Expand(variable.Expression)
+		e.variables[variable.Name] = &evalVariable{origin: origin, simple: true, value: value, static: static}
+		return
+	case "!=":
+		// the output of the shell command can't be known statically
+		e.variables[variable.Name] = &evalVariable{origin: origin, simple: true, value: "$(shell " + variable.Assignment + ")"}
+		return
+	}
+	e.variables[variable.Name] = &evalVariable{origin: origin, expr: variable.Expression}
+}
+
+// Flavor returns the flavor of the named variable, which is "simple",
+// "recursive" or "undefined" just like the flavor function of make reports
+func (e *Evaluator) Flavor(name string) string {
+	variable := e.variables[name]
+	switch {
+	case variable == nil:
+		return "undefined"
+	case variable.simple:
+		return "simple"
+	}
+	return "recursive"
+}
+
+// Value returns the expanded value of the named variable and reports
+// whether it is static, i.e. fully known. Undefined variables are empty and
+// not static.
+func (e *Evaluator) Value(name string) (string, bool) {
+	if e.variables[name] == nil {
+		return "", false
+	}
+	x := expander{evaluator: e, visiting: map[string]bool{}}
+	return x.value(name, "$("+name+")")
+}
+
+// Expand expands the expression with the values of the variables and
+// reports whether the result is static, i.e. fully known
+func (e *Evaluator) Expand(expr Expression) (string, bool) {
+	x := expander{evaluator: e, visiting: map[string]bool{}}
+	return x.expand(expr)
+}
+
+// ExpandWords expands the variable references in a list of words like the
+// targets or prerequisites of a rule and returns the resulting words
+func (e *Evaluator) ExpandWords(words []string) []string {
+	value, _ := e.Expand(ParseExpression(strings.Join(words, " ")))
+	return strings.Fields(value)
+}
+
+// expander expands a single expression. It keeps track of the recursive
+// variables being expanded to detect loops and of the variables bound by
+// foreach and call.
+type expander struct {
+	evaluator *Evaluator
+	visiting  map[string]bool
+	locals    map[string]string
+	// keepDollars keeps "$$" escaped instead of turning it into "$"
+	keepDollars bool
+}
+
+// expand expands all nodes of the expression
+func (x *expander) expand(expr Expression) (string, bool) {
+	var b strings.Builder
+	static := true
+	for _, node := range expr {
+		var value string
+		ok := true
+		switch node.Kind {
+		case TextNode:
+			value = node.Text
+			if !x.keepDollars {
+				value = strings.ReplaceAll(value, "$$", "$")
+			}
+		case ReferenceNode:
+			value, ok = x.reference(node)
+		case FunctionNode:
+			value, ok = x.function(node)
+		}
+		b.WriteString(value)
+		static = static && ok
+	}
+	return b.String(), static
+}
+
+// reference expands a variable or substitution reference
+func (x *expander) reference(node Node) (string, bool) {
+	name, ok := node.Name, true
+	if name == "" {
+		if name, ok = x.expand(node.NameExpr); !ok {
+			return node.Text, false
+		}
+	}
+	value, ok := x.value(name, node.Text)
+	if !ok || len(node.Args) != 2 {
+		return value, ok
+	}
+
+	pattern, ok := x.expand(node.Args[0])
+	if !ok {
+		return node.Text, false
+	}
+	replacement, ok := x.expand(node.Args[1])
+	if !ok {
+		return node.Text, false
+	}
+	// a substitution reference without "%" replaces suffixes
+	if !strings.Contains(pattern, "%") {
+		pattern, replacement = "%"+pattern, "%"+replacement
+	}
+	return patsubst(pattern, replacement, value), true
+}
+
+// value expands the named variable. The opaque text is returned for
+// variables which are undefined or reference themselves.
+func (x *expander) value(name, opaque string) (string, bool) {
+	if value, ok := x.locals[name]; ok {
+		return value, true
+	}
+	variable := x.evaluator.variables[name]
+	switch {
+	case variable == nil || x.visiting[name]:
+		return opaque, false
+	case variable.simple:
+		return variable.value, variable.static
+	}
+	x.visiting[name] = true
+	defer delete(x.visiting, name)
+	return x.expand(variable.expr)
+}
+
+// with returns an expander with additional local variables
+func (x *expander) with(locals map[string]string) *expander {
+	merged := map[string]string{}
+	for name, value := range x.locals {
+		merged[name] = value
+	}
+	for name, value := range locals {
+		merged[name] = value
+	}
+	return &expander{evaluator: x.evaluator, visiting: x.visiting, locals: merged, keepDollars: x.keepDollars}
+}
+
+// function evaluates a function call. Calls of functions which depend on
+// the system make runs on or have side effects are opaque.
+func (x *expander) function(node Node) (string, bool) {
+	switch node.Name {
+	case "if":
+		return x.conditional(node)
+	case "or", "and":
+		return x.logical(node)
+	case "foreach":
+		return x.foreach(node)
+	case "value", "flavor":
+		name, ok := x.expand(node.Args[0])
+		if !ok {
+			return node.Text, false
+		}
+		if node.Name == "flavor" {
+			return x.evaluator.Flavor(strings.TrimSpace(name)), true
+		}
+		variable := x.evaluator.variables[strings.TrimSpace(name)]
+		switch {
+		case variable == nil:
+			return node.Text, false
+		case variable.simple:
+			return variable.value, variable.static
+		}
+		return variable.expr.String(), true
+	}
+
+	fn, found := builtinFunctions[node.Name]
+	if !found || len(node.Args) < fn.minArgs {
+		return node.Text, false
+	}
+	args := make([]string, len(node.Args))
+	for i, arg := range node.Args {
+		value, ok := x.expand(arg)
+		if !ok {
+			return node.Text, false
+		}
+		args[i] = value
+	}
+	if node.Name == "call" {
+		return x.call(node, args)
+	}
+	if value, ok := fn.eval(args); ok {
+		return value, true
+	}
+	return node.Text, false
+}
+
+// conditional evaluates $(if condition,then[,else])
+func (x *expander) conditional(node Node) (string, bool) {
+	if len(node.Args) < 2 {
+		return node.Text, false
+	}
+	condition, ok := x.expand(node.Args[0])
+	switch {
+	case !ok:
+		return node.Text, false
+	case strings.TrimSpace(condition) != "":
+		return x.expand(node.Args[1])
+	case len(node.Args) > 2:
+		return x.expand(node.Args[2])
+	}
+	return "", true
+}
+
+// logical evaluates $(or ...) and $(and ...), which stop expanding their
+// arguments as soon as the result is known
+func (x *expander) logical(node Node) (string, bool) {
+	value := ""
+	for _, arg := range node.Args {
+		expanded, ok := x.expand(arg)
+		if !ok {
+			return node.Text, false
+		}
+		value = strings.TrimSpace(expanded)
+		if (node.Name == "or") == (value != "") {
+			return value, true
+		}
+	}
+	return value, true
+}
+
+// foreach evaluates $(foreach var,list,text)
+func (x *expander) foreach(node Node) (string, bool) {
+	if len(node.Args) < 3 {
+		return node.Text, false
+	}
+	name, ok := x.expand(node.Args[0])
+	if !ok {
+		return node.Text, false
+	}
+	list, ok := x.expand(node.Args[1])
+	if !ok {
+		return node.Text, false
+	}
+	var ret []string
+	for _, word := range strings.Fields(list) {
+		value, ok := x.with(map[string]string{strings.TrimSpace(name): word}).expand(node.Args[2])
+		if !ok {
+			return node.Text, false
+		}
+		ret = append(ret, value)
+	}
+	return strings.Join(ret, " "), true
+}
+
+// call evaluates $(call variable,param,...) with its arguments expanded
+func (x *expander) call(node Node, args []string) (string, bool) {
+	name := strings.TrimSpace(args[0])
+	locals := map[string]string{}
+	for i, arg := range args {
+		locals[strconv.Itoa(i)] = arg
+	}
+	locals["0"] = name
+
+	variable := x.evaluator.variables[name]
+	switch {
+	case variable == nil || x.visiting[name]:
+		return node.Text, false
+	case variable.simple:
+		return variable.value, variable.static
+	}
+	x.visiting[name] = true
+	defer delete(x.visiting, name)
+	// parameters of enclosing calls are hidden, see the make manual
+	inner := &expander{evaluator: x.evaluator, visiting: x.visiting, locals: locals, keepDollars: x.keepDollars}
+	return inner.expand(variable.expr)
+}
+
+// builtinFunction is a function of make which only depends on its expanded
+// arguments. eval reports false for invalid arguments.
+type builtinFunction struct {
+	minArgs int
+	eval    func(args []string) (string, bool)
+}
+
+// builtinFunctions holds the functions which can be evaluated statically.
+// call is listed to get its arguments expanded, it is evaluated separately.
+var builtinFunctions = map[string]builtinFunction{
+	"call": {1, nil},
+	"subst": {3, func(args []string) (string, bool) {
+		return strings.ReplaceAll(args[2], args[0], args[1]), true
+	}},
+	"patsubst": {3, func(args []string) (string, bool) {
+		return patsubst(strings.TrimSpace(args[0]), strings.TrimSpace(args[1]), args[2]), true
+	}},
+	"strip": {1, func(args []string) (string, bool) {
+		return strings.Join(strings.Fields(args[0]), " "), true
+	}},
+	"findstring": {2, func(args []string) (string, bool) {
+		if strings.Contains(args[1], args[0]) {
+			return args[0], true
+		}
+		return "", true
+	}},
+	"filter": {2, func(args []string) (string, bool) {
+		return filterWords(args[0], args[1], true), true
+	}},
+	"filter-out": {2, func(args []string) (string, bool) {
+		return filterWords(args[0], args[1], false), true
+	}},
+	"sort": {1, func(args []string) (string, bool) {
+		words := strings.Fields(args[0])
+		slices.Sort(words)
+		return strings.Join(slices.Compact(words), " "), true
+	}},
+	"word": {2, func(args []string) (string, bool) {
+		n, err := strconv.Atoi(strings.TrimSpace(args[0]))
+		words := strings.Fields(args[1])
+		switch {
+		case err != nil || n < 1:
+			return "", false
+		case n > len(words):
+			return "", true
+		}
+		return words[n-1], true
+	}},
+	"wordlist": {3, func(args []string) (string, bool) {
+		start, err := strconv.Atoi(strings.TrimSpace(args[0]))
+		if err != nil || start < 1 {
+			return "", false
+		}
+		end, err := strconv.Atoi(strings.TrimSpace(args[1]))
+		if err != nil || end < 0 {
+			return "", false
+		}
+		words := strings.Fields(args[2])
+		end = min(end, len(words))
+		if start > end {
+			return "", true
+		}
+		return strings.Join(words[start-1:end], " "), true
+	}},
+	"words": {1, func(args []string) (string, bool) {
+		return strconv.Itoa(len(strings.Fields(args[0]))), true
+	}},
+	"firstword": {1, func(args []string) (string, bool) {
+		words := strings.Fields(args[0])
+		if len(words) == 0 {
+			return "", true
+		}
+		return words[0], true
+	}},
+	"lastword": {1, func(args []string) (string, bool) {
+		words := strings.Fields(args[0])
+		if len(words) == 0 {
+			return "", true
+		}
+		return words[len(words)-1], true
+	}},
+	"dir": {1, func(args []string) (string, bool) {
+		return mapWords(args[0], func(word string) string {
+			if idx := strings.LastIndex(word, "/"); idx != -1 {
+				return word[:idx+1]
+			}
+			return "./"
+		}), true
+	}},
+	"notdir": {1, func(args []string) (string, bool) {
+		return mapWords(args[0], func(word string) string {
+			return word[strings.LastIndex(word, "/")+1:]
+		}), true
+	}},
+	"suffix": {1, func(args []string) (string, bool) {
+		return mapWords(args[0], func(word string) string {
+			return word[len(trimSuffix(word)):]
+		}), true
+	}},
+	"basename": {1, func(args []string) (string, bool) {
+		return mapWords(args[0], trimSuffix), true
+	}},
+	"addsuffix": {2, func(args []string) (string, bool) {
+		return mapWords(args[1], func(word string) string {
+			return word + args[0]
+		}), true
+	}},
+	"addprefix": {2, func(args []string) (string, bool) {
+		return mapWords(args[1], func(word string) string {
+			return args[0] + word
+		}), true
+	}},
+	"join": {2, func(args []string) (string, bool) {
+		first, second := strings.Fields(args[0]), strings.Fields(args[1])
+		ret := make([]string, max(len(first), len(second)))
+		for i := range ret {
+			if i < len(first) {
+				ret[i] = first[i]
+			}
+			if i < len(second) {
+				ret[i] += second[i]
+			}
+		}
+		return strings.Join(ret, " "), true
+	}},
+}
+
+// patsubst replaces the words of text matching pattern with replacement,
+// in which a "%" stands for the stem the pattern matched
+func patsubst(pattern, replacement, text string) string {
+	return mapWords(text, func(word string) string {
+		stem, ok := matchPattern(pattern, word)
+		switch {
+		case !ok:
+			return word
+		case strings.Contains(pattern, "%"):
+			return strings.Replace(replacement, "%", stem, 1)
+		}
+		return replacement
+	})
+}
+
+// filterWords returns the words of text which match (or don't match) any of
+// the space separated patterns
+func filterWords(patterns, text string, keep bool) string {
+	var ret []string
+	for _, word := range strings.Fields(text) {
+		matched := false
+		for _, pattern := range strings.Fields(patterns) {
+			if _, ok := matchPattern(pattern, word); ok {
+				matched = true
+				break
+			}
+		}
+		if matched == keep {
+			ret = append(ret, word)
+		}
+	}
+	return strings.Join(ret, " ")
+}
+
+// mapWords applies fn to every word of text
+func mapWords(text string, fn func(string) string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = fn(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// trimSuffix removes the suffix, i.e. the part starting at the last dot,
+// from the file name part of a word
+func trimSuffix(word string) string {
+	idx := strings.LastIndex(word, ".")
+	if idx == -1 || idx < strings.LastIndex(word, "/") {
+		return word
+	}
+	return word[:idx]
+}
+
+// joinWords joins two values separated by a space, like += does
+func joinWords(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func evaluate(t *testing.T, makefile string, opts EvalOptions) *Evaluator {
+	t.Helper()
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	return NewEvaluator(ret, opts)
+}
+
+func assertValue(t *testing.T, e *Evaluator, name, expected string) {
+	t.Helper()
+	value, static := e.Value(name)
+	assert.True(t, static, "value of %s should be static", name)
+	assert.Equal(t, expected, value, "value of %s", name)
+}
+
+func TestEvaluator_Flavors(t *testing.T) {
+	t.Parallel()
+	e := evaluate(t, `A = $(B)
+B = one
+C := $(B)
+B = two
+D ?= first
+D ?= second
+E := simple
+E += $(B)
+F = recursive
+F += $(B)
+B = three
+`, EvalOptions{})
+
+	assertValue(t, e, "A", "three")
+	assertValue(t, e, "C", "one")
+	assertValue(t, e, "D", "first")
+	assertValue(t, e, "E", "simple two")
+	assertValue(t, e, "F", "recursive three")
+
+	assert.Equal(t, "recursive", e.Flavor("A"))
+	assert.Equal(t, "simple", e.Flavor("C"))
+	assert.Equal(t, "simple", e.Flavor("E"))
+	assert.Equal(t, "recursive", e.Flavor("F"))
+	assert.Equal(t, "undefined", e.Flavor("G"))
+}
+
+func TestEvaluator_AppendInheritsFlavor(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseReader("Makefile", strings.NewReader("A := a\nA += b\nB = b\nB += c\nC += d\n"))
+	require.NoError(t, err)
+	require.Len(t, ret.Variables, 5)
+
+	assert.True(t, ret.Variables[1].SimplyExpanded)
+	assert.False(t, ret.Variables[3].SimplyExpanded)
+	assert.False(t, ret.Variables[4].SimplyExpanded)
+}
+
+func TestEvaluator_Overrides(t *testing.T) {
+	t.Parallel()
+	e := evaluate(t, `PREFIX = /usr/local
+CFLAGS = -O2
+override CFLAGS += -Wall
+LDFLAGS ?= -s
+HOME = /home/make
+HOME += more
+`, EvalOptions{
+		Overrides:   map[string]string{"PREFIX": "/opt", "CFLAGS": "-g"},
+		Environment: map[string]string{"LDFLAGS": "-L/lib", "HOME": "/root"},
+	})
+
+	assertValue(t, e, "PREFIX", "/opt")
+	assertValue(t, e, "CFLAGS", "-g -Wall")
+	assertValue(t, e, "LDFLAGS", "-L/lib")
+	assertValue(t, e, "HOME", "/home/make more")
+}
+
+func TestEvaluator_Opaque(t *testing.T) {
+	t.Parallel()
+	e := evaluate(t, `NOW := $(shell date)
+FILES = $(wildcard *.c) main.c
+OUT = $(UNDEFINED)/bin
+HASH != git rev-parse HEAD
+LOOP = $(LOOP) x
+`, EvalOptions{})
+
+	value, static := e.Value("NOW")
+	assert.False(t, static)
+	assert.Equal(t, "$(shell date)", value)
+
+	value, static = e.Value("FILES")
+	assert.False(t, static)
+	assert.Equal(t, "$(wildcard *.c) main.c", value)
+
+	value, static = e.Value("OUT")
+	assert.False(t, static)
+	assert.Equal(t, "$(UNDEFINED)/bin", value)
+
+	value, static = e.Value("HASH")
+	assert.False(t, static)
+	assert.Equal(t, "$(shell git rev-parse HEAD)", value)
+
+	value, static = e.Value("LOOP")
+	assert.False(t, static)
+	assert.Equal(t, "$(LOOP) x", value)
+
+	value, static = e.Value("UNDEFINED")
+	assert.False(t, static)
+	assert.Empty(t, value)
+}
+
+func TestEvaluator_Functions(t *testing.T) {
+	t.Parallel()
+	e := evaluate(t, `SRCS = src/main.c src/util.c lib/extra.c
+OBJS = $(SRCS:.c=.o)
+DEPS = $(patsubst %.c,%.d,$(filter src/%,$(SRCS)))
+NAMES = $(sort $(basename $(notdir $(SRCS))) main)
+DIRS = $(sort $(dir $(SRCS)))
+FIRST = $(firstword $(SRCS)) $(word 2,$(SRCS)) $(words $(SRCS))
+ARCH = amd64
+amd64_FLAGS = -m64
+FLAGS = $($(ARCH)_FLAGS)
+reverse = $(2) $(1)
+CALLED = $(call reverse,a,b)
+LOOPED = $(foreach d,a b,$(addprefix $(d)/,x))
+COND = $(if $(DEBUG),debug,release) $(or $(DEBUG),$(ARCH)) $(and yes,$(ARCH))
+RAW = $(value OBJS)
+EMPTY =
+DEBUG = $(EMPTY)
+`, EvalOptions{})
+
+	assertValue(t, e, "OBJS", "src/main.o src/util.o lib/extra.o")
+	assertValue(t, e, "DEPS", "src/main.d src/util.d")
+	assertValue(t, e, "NAMES", "extra main util")
+	assertValue(t, e, "DIRS", "lib/ src/")
+	assertValue(t, e, "FIRST", "src/main.c src/util.c 3")
+	assertValue(t, e, "FLAGS", "-m64")
+	assertValue(t, e, "CALLED", "b a")
+	assertValue(t, e, "LOOPED", "a/x b/x")
+	assertValue(t, e, "COND", "release amd64 amd64")
+	assertValue(t, e, "RAW", "$(SRCS:.c=.o)")
+}
+
+func TestEvaluator_ExpandWords(t *testing.T) {
+	t.Parallel()
+	e := evaluate(t, "TARGETS = all clean\nEXTRA := test\n", EvalOptions{})
+	assert.Equal(t, []string{"all", "clean", "test", "install"},
+		e.ExpandWords([]string{"$(TARGETS)", "$(EXTRA)", "install"}))
+}
+
+func TestEvaluator_BSDDialect(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseReaderWithOptions("Makefile", strings.NewReader(`A := ${B} $$HOME
+B = late
+C := ${B}
+`), ParseOptions{Dialect: BSD})
+	require.NoError(t, err)
+	e := NewEvaluator(ret, EvalOptions{})
+
+	// undefined variables and "$$" are kept by := in BSD make
+	assertValue(t, e, "A", "late $HOME")
+	assertValue(t, e, "C", "late")
+	assert.Equal(t, "recursive", e.Flavor("A"))
+}
@@ -0,0 +1,267 @@
+package parser
+
+import (
+	"strings"
+)
+
+// NodeKind classifies the nodes of an expression
+type NodeKind int
+
+const (
+	// TextNode is literal text, including escaped "$$"
+	TextNode NodeKind = iota
+	// ReferenceNode is a variable reference like "$(CC)", "${CC}", "$@" or
+	// a substitution reference like "$(SRCS:.c=.o)"
+	ReferenceNode
+	// FunctionNode is a call of a built-in function like "$(shell date)"
+	// or "$(call f,$(x))"
+	FunctionNode
+)
+
+// String returns the name of the node kind
+func (k NodeKind) String() string {
+	switch k {
+	case ReferenceNode:
+		return "reference"
+	case FunctionNode:
+		return "function"
+	}
+	return "text"
+}
+
+// Node represents a piece of a parsed expression
+type Node struct {
+	Kind NodeKind
+	// Text is the source text of the node, e.g. "$(CC)" or "$(shell date)"
+	Text string
+	// Name is the name of the referenced variable, e.g. "CC" or "@", or of
+	// the called function, e.g. "shell". It is empty if the name of a
+	// reference is computed like in "$($(ARCH)_FLAGS)".
+	Name string
+	// NameExpr holds the parsed name of a reference, which contains
+	// further references if the name is computed
+	NameExpr Expression
+	// Args holds the comma separated arguments of a function call. For a
+	// substitution reference it holds the pattern and the replacement.
+	Args []Expression
+	// Offset is the byte offset of the node in the parsed text
+	Offset int
+}
+
+// Expression represents text containing variable references and function
+// calls as parsed by make when expanding it
+type Expression []Node
+
+// functionArgs maps the built-in functions of GNU make to the maximum number
+// of arguments they take, 0 meaning any number. Commas beyond the maximum
+// are part of the last argument, like in "$(shell echo a,b)".
+var functionArgs = map[string]int{
+	"abspath": 1, "addprefix": 2, "addsuffix": 2, "and": 0, "basename": 1,
+	"call": 0, "dir": 1, "error": 1, "eval": 1, "file": 2, "filter": 2,
+	"filter-out": 2, "findstring": 2, "firstword": 1, "flavor": 1,
+	"foreach": 3, "guile": 1, "if": 3, "info": 1, "intcmp": 5, "join": 2,
+	"lastword": 1, "let": 3, "notdir": 1, "or": 0, "origin": 1,
+	"patsubst": 3, "realpath": 1, "shell": 1, "sort": 1, "strip": 1,
+	"subst": 3, "suffix": 1, "value": 1, "warning": 1, "wildcard": 1,
+	"word": 2, "wordlist": 3, "words": 1,
+}
+
+// ParseExpression parses the variable references and function calls in the
+// given text. Unterminated references like "$(CC" are kept as literal text.
+func ParseExpression(text string) Expression {
+	p := expressionParser{text: text}
+	ret, _ := p.parse(0, 0, 0, "")
+	return ret
+}
+
+// String returns the source text of the expression
+func (e Expression) String() string {
+	var b strings.Builder
+	for _, node := range e {
+		b.WriteString(node.Text)
+	}
+	return b.String()
+}
+
+// Walk calls fn for every node of the expression in order, descending into
+// the names and arguments of references and function calls unless fn
+// returns false for them
+func (e Expression) Walk(fn func(Node) bool) {
+	for _, node := range e {
+		if !fn(node) {
+			continue
+		}
+		node.NameExpr.Walk(fn)
+		for _, arg := range node.Args {
+			arg.Walk(fn)
+		}
+	}
+}
+
+// References returns the names of all variables referenced in the
+// expression, including nested references, in order of appearance.
+// Computed names are skipped.
+func (e Expression) References() []string {
+	var ret []string
+	e.Walk(func(node Node) bool {
+		if node.Kind == ReferenceNode && node.Name != "" {
+			ret = append(ret, node.Name)
+		}
+		return true
+	})
+	return ret
+}
+
+// Calls returns all calls of the named function in the expression,
+// including nested ones
+func (e Expression) Calls(function string) []Node {
+	var ret []Node
+	e.Walk(func(node Node) bool {
+		if node.Kind == FunctionNode && node.Name == function {
+			ret = append(ret, node)
+		}
+		return true
+	})
+	return ret
+}
+
+// expressionParser is a recursive descent parser for expressions
+type expressionParser struct {
+	text string
+}
+
+// parse parses the text starting at index i until the closing character of
+// the enclosing reference or one of the stop characters is found outside of
+// nested parentheses. It returns the expression and the index of the
+// terminating character, which is len(text) if there is none.
+func (p *expressionParser) parse(i int, open, close byte, stops string) (Expression, int) {
+	var ret Expression
+	start, depth := i, 0
+	flush := func(end int) {
+		if end > start {
+			ret = append(ret, Node{Kind: TextNode, Text: p.text[start:end], Offset: start})
+		}
+	}
+
+	for i < len(p.text) {
+		c := p.text[i]
+		switch {
+		case c == '$':
+			node, next, ok := p.reference(i)
+			if !ok {
+				i = next
+				continue
+			}
+			flush(i)
+			ret = append(ret, node)
+			i, start = next, next
+			continue
+		case open != 0 && c == open:
+			depth++
+		case close != 0 && c == close:
+			if depth == 0 {
+				flush(i)
+				return ret, i
+			}
+			depth--
+		case depth == 0 && strings.IndexByte(stops, c) != -1:
+			flush(i)
+			return ret, i
+		}
+		i++
+	}
+	flush(i)
+	return ret, i
+}
+
+// reference parses the reference or function call starting with the "$" at
+// index i. It returns false along with the index to continue at if there is
+// literal text instead, i.e. an escaped "$$" or an unterminated reference.
+func (p *expressionParser) reference(i int) (Node, int, bool) {
+	if i+1 >= len(p.text) {
+		return Node{}, i + 1, false
+	}
+
+	open := p.text[i+1]
+	var close byte
+	switch open {
+	case '$':
+		return Node{}, i + 2, false
+	case '(':
+		close = ')'
+	case '{':
+		close = '}'
+	default:
+		return Node{
+			Kind:     ReferenceNode,
+			Text:     p.text[i : i+2],
+			Name:     p.text[i+1 : i+2],
+			NameExpr: Expression{{Kind: TextNode, Text: p.text[i+1 : i+2], Offset: i + 1}},
+			Offset:   i,
+		}, i + 2, true
+	}
+
+	if node, end, ok := p.function(i, open, close); ok {
+		return node, end, true
+	}
+
+	node := Node{Kind: ReferenceNode, Offset: i}
+	name, end := p.parse(i+2, open, close, ":")
+	if end < len(p.text) && p.text[end] == ':' {
+		pattern, eq := p.parse(end+1, open, close, "=")
+		if eq < len(p.text) && p.text[eq] == '=' {
+			replacement, last := p.parse(eq+1, open, close, "")
+			node.Args = []Expression{pattern, replacement}
+			end = last
+		} else {
+			// a colon without "=" is part of the name, e.g. "$(a:b)"
+			name, end = p.parse(i+2, open, close, "")
+		}
+	}
+	if end >= len(p.text) {
+		return Node{}, i + 1, false
+	}
+
+	node.Text = p.text[i : end+1]
+	node.NameExpr = name
+	if len(name) == 1 && name[0].Kind == TextNode {
+		node.Name = name[0].Text
+	}
+	return node, end + 1, true
+}
+
+// function parses the call of a built-in function starting with the "$" at
+// index i, and reports whether there is one
+func (p *expressionParser) function(i int, open, close byte) (Node, int, bool) {
+	nameEnd := i + 2
+	for nameEnd < len(p.text) && (p.text[nameEnd] >= 'a' && p.text[nameEnd] <= 'z' || p.text[nameEnd] == '-') {
+		nameEnd++
+	}
+	name := p.text[i+2 : nameEnd]
+	maxArgs, ok := functionArgs[name]
+	if !ok || nameEnd >= len(p.text) || (p.text[nameEnd] != ' ' && p.text[nameEnd] != '\t') {
+		return Node{}, 0, false
+	}
+
+	node := Node{Kind: FunctionNode, Name: name, Offset: i}
+	pos := nameEnd
+	for pos < len(p.text) && (p.text[pos] == ' ' || p.text[pos] == '\t') {
+		pos++
+	}
+	for {
+		stops := ","
+		if maxArgs != 0 && len(node.Args)+1 >= maxArgs {
+			stops = ""
+		}
+		arg, end := p.parse(pos, open, close, stops)
+		if end >= len(p.text) {
+			return Node{}, 0, false
+		}
+		node.Args = append(node.Args, arg)
+		if p.text[end] == close {
+			node.Text = p.text[i : end+1]
+			return node, end + 1, true
+		}
+		pos = end + 1
+	}
+}
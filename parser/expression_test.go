@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpression_References(t *testing.T) {
+	t.Parallel()
+	expr := ParseExpression("$(CC) ${CFLAGS} -o $@ $< $$HOME")
+
+	assert.Equal(t, Expression{
+		{Kind: ReferenceNode, Text: "$(CC)", Name: "CC", NameExpr: Expression{{Kind: TextNode, Text: "CC", Offset: 2}}},
+		{Kind: TextNode, Text: " ", Offset: 5},
+		{Kind: ReferenceNode, Text: "${CFLAGS}", Name: "CFLAGS", NameExpr: Expression{{Kind: TextNode, Text: "CFLAGS", Offset: 8}}, Offset: 6},
+		{Kind: TextNode, Text: " -o ", Offset: 15},
+		{Kind: ReferenceNode, Text: "$@", Name: "@", NameExpr: Expression{{Kind: TextNode, Text: "@", Offset: 20}}, Offset: 19},
+		{Kind: TextNode, Text: " ", Offset: 21},
+		{Kind: ReferenceNode, Text: "$<", Name: "<", NameExpr: Expression{{Kind: TextNode, Text: "<", Offset: 23}}, Offset: 22},
+		{Kind: TextNode, Text: " $$HOME", Offset: 24},
+	}, expr)
+	assert.Equal(t, []string{"CC", "CFLAGS", "@", "<"}, expr.References())
+	assert.Equal(t, "$(CC) ${CFLAGS} -o $@ $< $$HOME", expr.String())
+}
+
+func TestParseExpression_FunctionCalls(t *testing.T) {
+	t.Parallel()
+	expr := ParseExpression("$(call f,$(x), b ,c)")
+	require.Len(t, expr, 1)
+
+	call := expr[0]
+	assert.Equal(t, FunctionNode, call.Kind)
+	assert.Equal(t, "call", call.Name)
+	assert.Equal(t, "$(call f,$(x), b ,c)", call.Text)
+	require.Len(t, call.Args, 4)
+	assert.Equal(t, "f", call.Args[0].String())
+	assert.Equal(t, ReferenceNode, call.Args[1][0].Kind)
+	assert.Equal(t, "x", call.Args[1][0].Name)
+	assert.Equal(t, " b ", call.Args[2].String())
+	assert.Equal(t, "c", call.Args[3].String())
+	assert.Equal(t, []string{"x"}, expr.References())
+}
+
+func TestParseExpression_ArgumentLimits(t *testing.T) {
+	t.Parallel()
+	// shell takes a single argument, so commas and parentheses are kept
+	expr := ParseExpression("$(shell echo a,b (c)) $(wildcard src/*.c) $(if $(DEBUG),-g,)")
+	require.Len(t, expr, 5)
+
+	assert.Equal(t, "shell", expr[0].Name)
+	require.Len(t, expr[0].Args, 1)
+	assert.Equal(t, "echo a,b (c)", expr[0].Args[0].String())
+
+	assert.Equal(t, "wildcard", expr[2].Name)
+	assert.Equal(t, "src/*.c", expr[2].Args[0].String())
+
+	assert.Equal(t, "if", expr[4].Name)
+	require.Len(t, expr[4].Args, 3)
+	assert.Equal(t, "DEBUG", expr[4].Args[0][0].Name)
+	assert.Equal(t, "-g", expr[4].Args[1].String())
+	assert.Empty(t, expr[4].Args[2])
+}
+
+func TestParseExpression_SubstitutionAndComputedNames(t *testing.T) {
+	t.Parallel()
+	expr := ParseExpression("$(SRCS:.c=.o) $($(ARCH)_FLAGS) $(info) $(word 2,$(LIST))")
+	require.Len(t, expr, 7)
+
+	subst := expr[0]
+	assert.Equal(t, ReferenceNode, subst.Kind)
+	assert.Equal(t, "SRCS", subst.Name)
+	require.Len(t, subst.Args, 2)
+	assert.Equal(t, ".c", subst.Args[0].String())
+	assert.Equal(t, ".o", subst.Args[1].String())
+
+	computed := expr[2]
+	assert.Equal(t, ReferenceNode, computed.Kind)
+	assert.Empty(t, computed.Name)
+	assert.Equal(t, "$(ARCH)_FLAGS", computed.NameExpr.String())
+
+	// without arguments a function name is a plain variable reference
+	assert.Equal(t, ReferenceNode, expr[4].Kind)
+	assert.Equal(t, "info", expr[4].Name)
+
+	assert.Equal(t, []string{"SRCS", "ARCH", "info", "LIST"}, expr.References())
+}
+
+func TestParseExpression_Nested(t *testing.T) {
+	t.Parallel()
+	expr := ParseExpression("$(patsubst %.c,%.o,$(filter %.c,$(wildcard $(SRCDIR)/*)))")
+	require.Len(t, expr, 1)
+
+	assert.Len(t, expr.Calls("patsubst"), 1)
+	assert.Len(t, expr.Calls("filter"), 1)
+	wildcard := expr.Calls("wildcard")
+	require.Len(t, wildcard, 1)
+	assert.Equal(t, "$(wildcard $(SRCDIR)/*)", wildcard[0].Text)
+	assert.Equal(t, 32, wildcard[0].Offset)
+	assert.Equal(t, []string{"SRCDIR"}, expr.References())
+}
+
+func TestParseExpression_Unterminated(t *testing.T) {
+	t.Parallel()
+	expr := ParseExpression("$(CC -o $(OUT) $")
+	assert.Equal(t, "$(CC -o $(OUT) $", expr.String())
+	assert.Equal(t, []string{"OUT"}, expr.References())
+}
+
+func TestParse_Expressions(t *testing.T) {
+	t.Parallel()
+	makefile := `BUILDTIME = $(shell date +%s)
+define HELP
+usage: $(MAKE) $(TARGETS)
+endef
+build: CFLAGS += $(DEBUG_FLAGS)
+build:
+	$(CC) $(CFLAGS) -o $@ $^
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Variables, 2)
+	require.Len(t, ret.TargetVariables, 1)
+	require.Len(t, ret.Rules, 1)
+
+	shell := ret.Variables[0].Expression.Calls("shell")
+	require.Len(t, shell, 1)
+	assert.Equal(t, "date +%s", shell[0].Args[0].String())
+
+	assert.Equal(t, []string{"MAKE", "TARGETS"}, ret.Variables[1].Expression.References())
+	assert.Equal(t, []string{"DEBUG_FLAGS"}, ret.TargetVariables[0].Expression.References())
+
+	require.Len(t, ret.Rules[0].Recipe, 1)
+	assert.Equal(t, []string{"CC", "CFLAGS", "@", "^"}, ret.Rules[0].Recipe[0].Expression.References())
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// fileSystem abstracts where the parser reads Makefiles and included files
+// from. Without an fs.FS the operating system's file system is used.
+type fileSystem struct {
+	fsys fs.FS
+}
+
+// open opens the named file for reading
+func (f fileSystem) open(name string) (io.ReadCloser, error) {
+	var (
+		file io.ReadCloser
+		err  error
+	)
+	if f.fsys == nil {
+		file, err = os.Open(name)
+	} else {
+		file, err = f.fsys.Open(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error opening the provided filepath '%s'", name)
+	}
+	return file, nil
+}
+
+// glob returns the names of all files matching the pattern
+func (f fileSystem) glob(pattern string) ([]string, error) {
+	if f.fsys == nil {
+		return filepath.Glob(pattern)
+	}
+	return fs.Glob(f.fsys, pattern)
+}
+
+// isFile reports whether the named file exists and is a regular file
+func (f fileSystem) isFile(name string) bool {
+	var (
+		info fs.FileInfo
+		err  error
+	)
+	if f.fsys == nil {
+		info, err = os.Stat(name)
+	} else {
+		info, err = fs.Stat(f.fsys, name)
+	}
+	return err == nil && !info.IsDir()
+}
+
+// join joins path elements with the separator of the file system
+func (f fileSystem) join(elem ...string) string {
+	if f.fsys == nil {
+		return filepath.Join(elem...)
+	}
+	return path.Join(elem...)
+}
+
+// dir returns the directory of the named file
+func (f fileSystem) dir(name string) string {
+	if f.fsys == nil {
+		return filepath.Dir(name)
+	}
+	return path.Dir(name)
+}
+
+// isAbs reports whether the path is absolute. Paths in an fs.FS are always
+// relative to its root.
+func (f fileSystem) isAbs(name string) bool {
+	if f.fsys == nil {
+		return filepath.IsAbs(name)
+	}
+	return false
+}
+
+// canonical returns a canonical version of the path to compare files by
+func (f fileSystem) canonical(name string) string {
+	if f.fsys != nil {
+		return path.Clean(name)
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return name
+}
@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/checkmake/checkmake/logger"
+)
+
+// Include represents an include, -include or sinclude directive, or one of
+// the include directives of BSD make like .include
+type Include struct {
+	Directive string
+	// Paths holds the file names as written in the directive
+	Paths []string
+	// Optional is set for -include and sinclude, which don't fail if the
+	// included file doesn't exist
+	Optional bool
+	// System is set for BSD make includes like ".include <bsd.prog.mk>",
+	// which are only looked up in the include directories
+	System bool
+	// Resolved holds the files which were found and parsed, this is only
+	// filled when the parser follows includes
+	Resolved []string
+	// Missing holds the paths which could not be found in any of the
+	// searched directories, this is only filled when the parser follows
+	// includes
+	Missing []string
+	// Cycles holds the files which were already being parsed when the
+	// include was reached, which are not followed again
+	Cycles     []string
+	FileName   string
+	LineNumber int
+	Range      Range
+	Conditions []ConditionalBranchRef
+}
+
+// IncludeList represents a list of includes
+type IncludeList []Include
+
+// reFindInclude captures include directives.
+// Group 1: The directive (include, -include or sinclude).
+// Group 2: The space separated list of files to include.
+var reFindInclude = regexp.MustCompile(`^\s*(-include|sinclude|include)\s+(.*)$`)
+
+// isInclude reports whether the line is an include directive
+func isInclude(line string) bool {
+	matches := reFindInclude.FindStringSubmatch(line)
+	if matches == nil {
+		return false
+	}
+	// guard against variables named like the directive, e.g. "include = foo"
+	rest := strings.TrimLeft(strings.TrimSpace(matches[2]), ":?+!")
+	return !strings.HasPrefix(rest, "=")
+}
+
+// parseInclude parses an include directive spanning the given range into an
+// Include struct
+func parseInclude(line string, rng Range) Include {
+	matches := reFindInclude.FindStringSubmatch(line)
+	paths := matches[2]
+	if idx := strings.Index(paths, "#"); idx != -1 {
+		paths = paths[:idx]
+	}
+
+	return Include{
+		Directive:  matches[1],
+		Paths:      strings.Fields(paths),
+		Optional:   matches[1] != "include",
+		FileName:   rng.FileName,
+		LineNumber: rng.Start.Line,
+		Range:      rng,
+	}
+}
+
+// includeResolver finds included files and keeps track of the files which
+// are currently being parsed to detect include cycles
+type includeResolver struct {
+	files      fileSystem
+	searchDirs []string
+	visiting   []string
+}
+
+// newIncludeResolver returns an includeResolver for the given top level
+// Makefile and additional search directories
+func newIncludeResolver(files fileSystem, filepath string, searchDirs []string) *includeResolver {
+	return &includeResolver{
+		files:      files,
+		searchDirs: searchDirs,
+		visiting:   []string{files.canonical(filepath)},
+	}
+}
+
+// follow resolves all paths of the include directive and parses the found
+// files into the passed in Makefile
+func (r *includeResolver) follow(include *Include, ret *Makefile, conditionals *conditionalStack) error {
+	for _, path := range include.Paths {
+		if strings.Contains(path, "$") {
+			logger.Debug(fmt.Sprintf("Unable to resolve included file %q without evaluating variables", path))
+			continue
+		}
+
+		files := r.resolve(path, include.FileName, include.System)
+		if len(files) == 0 {
+			include.Missing = append(include.Missing, path)
+			continue
+		}
+
+		for _, file := range files {
+			if r.isVisiting(file) {
+				include.Cycles = append(include.Cycles, file)
+				continue
+			}
+			include.Resolved = append(include.Resolved, file)
+
+			child := &conditionalStack{
+				fileName: file,
+				nextID:   conditionals.nextID,
+				outer:    conditionals.scope(),
+			}
+			r.visiting = append(r.visiting, r.files.canonical(file))
+			err := parseFile(r.files, file, ret, child, r)
+			r.visiting = r.visiting[:len(r.visiting)-1]
+			if err != nil {
+				return err
+			}
+			conditionals.nextID = child.nextID
+			conditionals.adopt(child.closed)
+		}
+	}
+	return nil
+}
+
+// cycleErrors returns the parse errors for the include cycles of the
+// include directive the scanner resides on
+func cycleErrors(scanner *MakefileScanner, include Include) (ret []ParseError) {
+	for _, file := range include.Cycles {
+		ret = append(ret, *newParseError(scanner, fmt.Sprintf("include cycle: %q is already being parsed", file)))
+	}
+	return
+}
+
+// resolve looks up an included path relative to the including file first
+// and then in the configured search directories. System includes are only
+// looked up in the search directories. Paths can contain glob patterns, in
+// which case all matches from the first directory with any matches are
+// returned.
+func (r *includeResolver) resolve(path, includingFile string, system bool) []string {
+	if r.files.isAbs(path) {
+		return r.existingFiles(path)
+	}
+
+	dirs := r.searchDirs
+	if !system {
+		dirs = append([]string{r.files.dir(includingFile)}, r.searchDirs...)
+	}
+	for _, dir := range dirs {
+		if files := r.existingFiles(r.files.join(dir, path)); len(files) > 0 {
+			return files
+		}
+	}
+	return nil
+}
+
+// isVisiting reports whether the file is currently being parsed
+func (r *includeResolver) isVisiting(file string) bool {
+	canonical := r.files.canonical(file)
+	for _, visiting := range r.visiting {
+		if visiting == canonical {
+			return true
+		}
+	}
+	return false
+}
+
+// existingFiles returns the regular files matching the given path, which
+// may be a glob pattern
+func (r *includeResolver) existingFiles(path string) []string {
+	candidates := []string{path}
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := r.files.glob(path)
+		if err != nil {
+			return nil
+		}
+		candidates = matches
+	}
+
+	ret := []string{}
+	for _, candidate := range candidates {
+		if r.files.isFile(candidate) {
+			ret = append(ret, candidate)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_IncludesAreRecordedButNotFollowed(t *testing.T) {
+	t.Parallel()
+	ret, err := Parse("../fixtures/includes/Makefile")
+	require.NoError(t, err)
+
+	require.Len(t, ret.Includes, 3)
+	assert.Equal(t, "include", ret.Includes[0].Directive)
+	assert.Equal(t, []string{"mk/targets.mk"}, ret.Includes[0].Paths)
+	assert.False(t, ret.Includes[0].Optional)
+	assert.Equal(t, 2, ret.Includes[0].LineNumber)
+	assert.Empty(t, ret.Includes[0].Resolved)
+
+	assert.Equal(t, "-include", ret.Includes[1].Directive)
+	assert.True(t, ret.Includes[1].Optional)
+	assert.Equal(t, []string{"common.mk", "mk/missing.mk"}, ret.Includes[2].Paths)
+
+	require.Len(t, ret.Rules, 2)
+	assert.Equal(t, ".PHONY", ret.Rules[0].Target)
+	assert.Equal(t, "all", ret.Rules[1].Target)
+}
+
+func TestParseWithOptions_FollowIncludes(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseWithOptions("../fixtures/includes/Makefile", ParseOptions{
+		FollowIncludes: true,
+		IncludeDirs:    []string{"../fixtures/includes/shared"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "../fixtures/includes/Makefile", ret.FileName)
+
+	targets := map[string]Rule{}
+	for _, rule := range ret.Rules {
+		targets[rule.Target] = rule
+	}
+	require.Contains(t, targets, "clean")
+	assert.Equal(t, "../fixtures/includes/mk/targets.mk", targets["clean"].FileName)
+	assert.Equal(t, 3, targets["clean"].LineNumber)
+	require.Contains(t, targets, "all")
+	assert.Equal(t, "../fixtures/includes/Makefile", targets["all"].FileName)
+
+	variables := map[string]Variable{}
+	for _, variable := range ret.Variables {
+		variables[variable.Name] = variable
+	}
+	require.Contains(t, variables, "VERSION")
+	assert.Equal(t, "../fixtures/includes/mk/targets.mk", variables["VERSION"].FileName)
+	require.Contains(t, variables, "SHARED")
+	assert.Equal(t, "../fixtures/includes/shared/common.mk", variables["SHARED"].FileName)
+
+	// the include of the top level Makefile from mk/targets.mk is a cycle and
+	// must not be followed
+	require.Len(t, ret.Includes, 4)
+	cycle := ret.Includes[0]
+	assert.Equal(t, "../fixtures/includes/mk/targets.mk", cycle.FileName)
+	assert.Empty(t, cycle.Resolved)
+	assert.Empty(t, cycle.Missing)
+	assert.Equal(t, []string{"../fixtures/includes/Makefile"}, cycle.Cycles)
+	require.Len(t, ret.Errors, 1)
+	assert.Equal(t, `include cycle: "../fixtures/includes/Makefile" is already being parsed`, ret.Errors[0].Message)
+	assert.Equal(t, "../fixtures/includes/mk/targets.mk", ret.Errors[0].FileName)
+	assert.Equal(t, 12, ret.Errors[0].LineNumber)
+
+	assert.Equal(t, []string{"../fixtures/includes/mk/targets.mk"}, ret.Includes[1].Resolved)
+	assert.Equal(t, []string{"mk/optional.mk"}, ret.Includes[2].Missing)
+	assert.Equal(t, []string{"../fixtures/includes/shared/common.mk"}, ret.Includes[3].Resolved)
+	assert.Equal(t, []string{"mk/missing.mk"}, ret.Includes[3].Missing)
+}
+
+func TestParseWithOptions_IncludesInheritConditionalScope(t *testing.T) {
+	t.Parallel()
+	makefile := `
+ifdef WITH_TARGETS
+include targets.mk
+endif
+`
+	dir := t.TempDir()
+	tmp := filepath.Join(dir, "Makefile")
+	require.NoError(t, os.WriteFile(tmp, []byte(makefile), 0o600))
+	included := filepath.Join(dir, "targets.mk")
+	require.NoError(t, os.WriteFile(included, []byte("ifeq ($(OS),Windows_NT)\nclean:\n\tdel build\nendif\n"), 0o600))
+
+	ret, err := ParseWithOptions(tmp, ParseOptions{FollowIncludes: true})
+	require.NoError(t, err)
+
+	require.Len(t, ret.Rules, 1)
+	assert.Equal(t, included, ret.Rules[0].FileName)
+	assert.Equal(t, []ConditionalBranchRef{{0, 0}, {1, 0}}, ret.Rules[0].Conditions)
+
+	require.Len(t, ret.Conditionals, 1)
+	require.Len(t, ret.Conditionals[0].Branches[0].Conditionals, 1)
+	nested := ret.Conditionals[0].Branches[0].Conditionals[0]
+	assert.Equal(t, included, nested.FileName)
+	require.Len(t, nested.Branches[0].Rules, 1)
+	assert.Equal(t, "clean", nested.Branches[0].Rules[0].Target)
+}
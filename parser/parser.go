@@ -7,7 +7,10 @@ package parser
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/checkmake/checkmake/logger"
@@ -15,18 +18,93 @@ import (
 
 // Makefile provides a data structure to describe a parsed Makefile
 type Makefile struct {
-	FileName  string
-	Rules     RuleList
-	Variables VariableList
+	FileName string
+	// Dialect is the dialect of make the Makefile was parsed as
+	Dialect      Dialect
+	Rules        RuleList
+	Variables    VariableList
+	Conditionals ConditionalList
+	Includes     IncludeList
+	// TargetVariables holds all target-specific and pattern-specific
+	// variable assignments, whether or not a rule for the target exists
+	TargetVariables VariableList
+	// Exports holds the standalone export and unexport directives
+	Exports ExportList
+	// Undefines holds the undefine directives
+	Undefines UndefineList
+	// VPaths holds the vpath directives and assignments to VPATH
+	VPaths VPathList
+	// Diagnostics holds the lines the parser could not make sense of
+	Diagnostics DiagnosticList
+	// Errors holds the malformed constructs the parser skipped, which
+	// includes the lines in Diagnostics as well as unbalanced conditionals
+	// and unterminated define blocks
+	Errors ParseErrorList
+	// Settings holds the file-level settings made via special targets like
+	// .ONESHELL and special variables like .RECIPEPREFIX
+	Settings Settings
+	// Comments holds all comments in the order they appear in, both on
+	// lines of their own and trailing rules or variables
+	Comments CommentList
+	// CST is the concrete syntax tree of the Makefile, which everything
+	// else is derived from. Included files are not part of it.
+	CST *CST
 }
 
 // Rule represents a Make rule
 type Rule struct {
-	Target       string
-	Dependencies []string
-	Body         []string
-	FileName     string
-	LineNumber   int
+	// Target is the target list as written, e.g. "clean distclean"
+	Target string
+	// Targets holds the individual targets of the rule
+	Targets []string
+	// Grouped is set for grouped targets ("a b &: c"), which are all
+	// updated by a single invocation of the recipe
+	Grouped bool
+	// Kind tells explicit rules from pattern, static pattern and suffix rules
+	Kind RuleKind
+	// TargetPatterns holds the "%" patterns the rule's targets are matched
+	// against, which are the targets of pattern rules, the target pattern
+	// of static pattern rules and the suffix of suffix rules as "%.o".
+	// It is empty for explicit rules.
+	TargetPatterns []string
+	// PrerequisitePatterns holds the prerequisites containing the "%" stem,
+	// suffix rules report their source suffix as "%.c"
+	PrerequisitePatterns []string
+	Dependencies         []string
+	// OrderOnlyDependencies holds the prerequisites listed after a "|",
+	// which are built before the target but don't cause it to be rebuilt
+	OrderOnlyDependencies []string
+	// DoubleColon is set for "target::" rules, of which a target may have
+	// several, each with its own recipe
+	DoubleColon bool
+	// Body holds the recipe lines as text, see Recipe for their details
+	Body []string
+	// Recipe holds the recipe lines with their prefixes parsed
+	Recipe     RecipeLineList
+	FileName   string
+	LineNumber int
+	// Range spans the rule from its target up to the end of its recipe
+	Range Range
+	// TargetVariables holds the target-specific variable assignments for
+	// the rule's target(s), e.g. "debug: CFLAGS += -g"
+	TargetVariables VariableList
+	// Conditions lists the conditional branches, outermost first, the rule
+	// is defined in. It is empty for unconditional rules.
+	Conditions []ConditionalBranchRef
+	// Doc holds the comment lines directly preceding the rule
+	Doc CommentList
+	// TrailingComment is the comment at the end of the rule's target line,
+	// if any
+	TrailingComment *Comment
+}
+
+// TargetNames returns the individual targets of the rule. Rules which were
+// not created by the parser and only have Target set are split on whitespace.
+func (r Rule) TargetNames() []string {
+	if len(r.Targets) > 0 {
+		return r.Targets
+	}
+	return strings.Fields(r.Target)
 }
 
 // RuleList represents a list of rules
@@ -34,102 +112,449 @@ type RuleList []Rule
 
 // Variable represents a Make variable
 type Variable struct {
-	Name            string
-	SimplyExpanded  bool
-	Assignment      string
+	Name           string
+	SimplyExpanded bool
+	// Operator is the assignment operator used, e.g. "=", ":=" or "+="
+	Operator   string
+	Assignment string
+	// Expression holds the variable references and function calls parsed
+	// from the assignment
+	Expression      Expression
 	SpecialVariable bool
-	FileName        string
-	LineNumber      int
+	// Override, Exported and Private are set if the assignment carries the
+	// respective override, export or private modifier
+	Override bool
+	Exported bool
+	Private  bool
+	// Define is set for multi-line variables defined by a define block
+	Define bool
+	// Targets holds the targets or patterns a target-specific variable
+	// assignment applies to. It is empty for global variables.
+	Targets    []string
+	FileName   string
+	LineNumber int
+	Range      Range
+	// Conditions lists the conditional branches, outermost first, the
+	// variable is defined in. It is empty for unconditional variables.
+	Conditions []ConditionalBranchRef
+	// Doc holds the comment lines directly preceding the variable
+	Doc CommentList
+	// TrailingComment is the comment at the end of the assignment, which
+	// is not part of the assigned value, if any
+	TrailingComment *Comment
 }
 
 // VariableList represents a list of variables
 type VariableList []Variable
 
+// simplyExpanded reports whether the last assignment to the named variable
+// in the list is simply expanded, which an append to it inherits
+func (l VariableList) simplyExpanded(name string) bool {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].Name == name {
+			return l[i].SimplyExpanded
+		}
+	}
+	return false
+}
+
+// variableName matches the name of a variable in an assignment, which may
+// be computed from variable references like "$(ARCH)_FLAGS". References
+// may contain one more level of references, like "$($(ARCH)_NAME)".
+const variableName = `(?:[A-Za-z0-9_.-]|\$\((?:[^()]|\([^()]*\))*\)|\$\{(?:[^{}]|\{[^{}]*\})*\})+`
+
 var (
 	// Group 1: The target(s). This is intentionally broad, allowing for special characters (%, .),
 	//          variables ($(), ${}), spaces (for multiple targets), and file paths.
-	// Group 2: Everything after the colon (prerequisites and/or an inline recipe).
+	// Group 2: "&" for grouped targets ("a b &: c"), empty otherwise.
+	// Group 3: Everything after the colon (prerequisites and/or an inline recipe).
 	// Notes: This pattern intentionally excludes variable assignments (":=", "?=", "+=", "!=")
 	//        by ensuring that ':' is not immediately followed by '='.
-	reFindRule = regexp.MustCompile(`^([A-Za-z0-9_.%/\-$(){}\s]+)\s*:(\s*[^=].*)?$`)
+	reFindRule = regexp.MustCompile(`^([A-Za-z0-9_.%/\-$(){}\s]+?)\s*(&?):(\s*[^=].*)?$`)
+
+	// reFindVariableModifiers captures the modifiers of a variable assignment.
+	// Group 1: The modifiers, e.g. "override export ".
+	// Group 2: The assignment following them, which doesn't start with an
+	//          operator, so "export = foo" assigns a variable named export.
+	reFindVariableModifiers = regexp.MustCompile(`^((?:(?:override|export|private)\s+)+)([^\s=:?+!].*)$`)
 
-	// reFindRuleBody captures a line belonging to a rule's recipe.
-	// It must start with a tab.
-	// Group 1: The command to be executed.
-	reFindRuleBody = regexp.MustCompile(`^\t+(.*)`)
+	// reFindTargetVariable captures target-specific and pattern-specific
+	// variable assignments like "debug: CFLAGS += -g".
+	// Group 1: The target(s) or pattern(s).
+	// Group 2: Modifiers like override, export or private, if any.
+	// Group 3: The variable name.
+	// Group 4: The assignment operator.
+	// Group 5: The value being assigned.
+	reFindTargetVariable = regexp.MustCompile(`^([A-Za-z0-9_.%/\-$(){}\s]+?)\s*:\s*((?:(?:override|export|private)\s+)*)(` + variableName + `)\s*(=|:{1,3}=|[?+!]=)\s*(.*)$`)
 
 	// reFindSimpleVariable captures simple/immediate variable assignments.
 	// This includes ':=', '::=', and ':::='.
-	// Group 1: The variable name (alphanumeric, underscore, dot, hyphen).
-	// Group 2: The value being assigned.
-	reFindSimpleVariable = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*:{1,3}=\s*(.*)`)
+	// Group 1: The variable name (alphanumeric, underscore, dot, hyphen and
+	//          variable references).
+	// Group 2: The operator itself (':=', '::=' or ':::=').
+	// Group 3: The value being assigned.
+	reFindSimpleVariable = regexp.MustCompile(`^(` + variableName + `)\s*(:{1,3}=)\s*(.*)`)
 
 	// reFindExpandedVariable captures recursively expanded variable assignments ('=').
 	// Group 1: The variable name.
 	// Group 2: The value being assigned.
-	reFindExpandedVariable = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*=\s*(.*)`)
+	reFindExpandedVariable = regexp.MustCompile(`^(` + variableName + `)\s*=\s*(.*)`)
 
 	// reFindOtherVariable captures all other assignment operators.
 	// This includes conditional ('?='), shell ('!='), and append ('+=').
 	// Group 1: The variable name.
 	// Group 2: The operator itself ('?=', '!=', or '+=').
 	// Group 3: The value being assigned.
-	reFindOtherVariable = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*([?!+]=)\s*(.*)`)
+	reFindOtherVariable = regexp.MustCompile(`^(` + variableName + `)\s*([?!+]=)\s*(.*)`)
 
 	// reFindSpecialTarget captures special Make targets that start with a dot, like .PHONY.
 	// Group 1: The special target name (e.g., ".PHONY").
 	// Group 2: The prerequisites/dependencies (e.g., "all clean test").
-	reFindSpecialTarget = regexp.MustCompile(`^(\.[A-Za-z_]+)\s*:(.*)`)
+	// Assignments like ".DEFAULT_GOAL := all" are left to the variable regexes.
+	reFindSpecialTarget = regexp.MustCompile(`^(\.[A-Z_]+)\s*:($|[^=:].*)`)
 )
 
+// ParseOptions holds optional settings for parsing a Makefile
+type ParseOptions struct {
+	// FollowIncludes makes the parser follow include, -include and sinclude
+	// directives and merge the rules and variables of the included files
+	FollowIncludes bool
+	// IncludeDirs are additional directories to search for included files,
+	// similar to the -I flag of make
+	IncludeDirs []string
+	// FS is the file system to read the Makefile and included files from.
+	// If it is nil, the operating system's file system is used.
+	FS fs.FS
+	// Strict makes parsing fail with a ParseErrorList if the Makefile
+	// contains malformed constructs. The Makefile is parsed completely and
+	// returned nonetheless.
+	Strict bool
+	// Dialect selects the dialect of make to parse the Makefile as. It
+	// defaults to GNU make.
+	Dialect Dialect
+}
+
 // Parse is the main function to parse a Makefile from a file path string to a
 // Makefile struct. This function should be kept fairly small and ideally most
 // of the heavy lifting will live in the specific parsing functions below that
 // know how to deal with individual lines.
 func Parse(filepath string) (ret Makefile, err error) {
-	ret.FileName = filepath
-	var scanner *MakefileScanner
-	scanner, err = NewMakefileScanner(filepath)
+	return ParseWithOptions(filepath, ParseOptions{})
+}
+
+// ParseWithOptions parses a Makefile like Parse does, but allows to
+// configure optional parser behavior like following includes
+func ParseWithOptions(filepath string, opts ParseOptions) (ret Makefile, err error) {
+	files := fileSystem{fsys: opts.FS}
+	file, err := files.open(filepath)
 	if err != nil {
+		ret.FileName = filepath
 		return ret, err
 	}
+	defer file.Close()
+
+	return parse(filepath, file, files, opts)
+}
+
+// ParseReader parses a Makefile from the given reader, e.g. stdin or
+// generated content. The name is used as the file name of the Makefile and
+// everything parsed from it.
+func ParseReader(name string, r io.Reader) (Makefile, error) {
+	return ParseReaderWithOptions(name, r, ParseOptions{})
+}
+
+// ParseReaderWithOptions parses a Makefile from the given reader like
+// ParseReader does, but allows to configure optional parser behavior.
+// Included files are looked up relative to the directory of name.
+func ParseReaderWithOptions(name string, r io.Reader, opts ParseOptions) (Makefile, error) {
+	return parse(name, r, fileSystem{fsys: opts.FS}, opts)
+}
+
+// ParseFS parses the named Makefile from the given file system
+func ParseFS(fsys fs.FS, name string) (Makefile, error) {
+	return ParseWithOptions(name, ParseOptions{FS: fsys})
+}
+
+// parse parses the top level Makefile read from r
+func parse(name string, r io.Reader, files fileSystem, opts ParseOptions) (ret Makefile, err error) {
+	ret.FileName = name
+	ret.Dialect = opts.Dialect
+	conditionals := &conditionalStack{fileName: name}
+
+	var includes *includeResolver
+	if opts.FollowIncludes {
+		includes = newIncludeResolver(files, name, opts.IncludeDirs)
+	}
+
+	if ret.CST, err = newCST(name, r); err != nil {
+		return
+	}
+	err = parseScanner(newTreeScanner(ret.CST), &ret, conditionals, includes)
+	ret.CST.nest()
+	classifySuffixRules(ret.Rules, ret.Settings.KnownSuffixes())
+	ret.Conditionals = conditionals.closed
+	attachToConditionals(ret.Conditionals, ret.Rules, ret.Variables)
+	attachTargetVariables(ret.Rules, ret.TargetVariables)
+	if err == nil && opts.Strict && len(ret.Errors) > 0 {
+		err = ret.Errors
+	}
+	return
+}
+
+// attachTargetVariables adds every target-specific variable assignment to
+// the rules defining one of the targets it applies to
+func attachTargetVariables(ruleList RuleList, targetVariables VariableList) {
+	for i := range ruleList {
+		targets := ruleList[i].TargetNames()
+		for _, variable := range targetVariables {
+			for _, target := range variable.Targets {
+				if slices.Contains(targets, target) {
+					ruleList[i].TargetVariables = append(ruleList[i].TargetVariables, variable)
+					break
+				}
+			}
+		}
+	}
+}
+
+// parseFile opens and parses a single file, e.g. an included one, and adds
+// everything found in it to the passed in Makefile
+func parseFile(files fileSystem, filepath string, ret *Makefile, conditionals *conditionalStack, includes *includeResolver) error {
+	file, err := files.open(filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tree, err := newCST(filepath, file)
+	if err != nil {
+		return err
+	}
+	return parseScanner(newTreeScanner(tree), ret, conditionals, includes)
+}
+
+// parseScanner parses everything the scanner provides and adds it to the
+// passed in Makefile. If includes is not nil, included files are parsed
+// recursively into the same Makefile.
+func parseScanner(scanner *MakefileScanner, ret *Makefile, conditionals *conditionalStack, includes *includeResolver) error {
+	// inRecipe tracks whether lines starting with the recipe prefix, a tab
+	// by default, belong to the recipe of the last rule, which allows
+	// recipes to continue after conditional directives, comments or empty
+	// lines
+	inRecipe := false
+	// doc collects consecutive comment lines, which document the rule or
+	// variable following them
+	var doc CommentList
+	// ruleNode is the node of the last rule, which recipe lines belong to
+	var ruleNode *CSTNode
 
 	for {
+		pendingDoc := doc
+		doc = nil
+
 		switch {
-		case strings.HasPrefix(scanner.Text(), "#"):
-			// parse comments here, ignoring them for now
+		case inRecipe && isRecipeLine(scanner.Text(), ret.Settings.RecipePrefix):
+			last := &ret.Rules[len(ret.Rules)-1]
+			body := recipeBody(scanner, ret.Settings.RecipePrefix)
+			last.Body = append(last.Body, body)
+			last.Recipe = append(last.Recipe, parseRecipeLine(body, scanner.Range()))
+			last.Range.End = scanner.Range().End
+			scanner.mark(CSTRecipe, ruleNode)
+			scanner.Scan()
+		case isComment(scanner.Text()):
+			scanner.mark(CSTComment, nil)
+			comment := parseComment(scanner)
+			ret.Comments = append(ret.Comments, comment)
+			doc = append(docFor(pendingDoc, comment.LineNumber), comment)
+			scanner.Scan()
+		case ret.Dialect == BSD && isBSDConditional(scanner.Text()):
+			scanner.mark(CSTConditional, nil)
+			if parseErr := conditionals.handleBSD(scanner.Text(), scanner.Range()); parseErr != nil {
+				ret.Errors = append(ret.Errors, *parseErr)
+			}
+			scanner.Scan()
+		case ret.Dialect == BSD && isBSDInclude(scanner.Text()):
+			scanner.mark(CSTInclude, nil)
+			include := parseBSDInclude(scanner.Text(), scanner.Range())
+			include.Conditions = conditionals.scope()
+			if includes != nil {
+				if err := includes.follow(&include, ret, conditionals); err != nil {
+					return err
+				}
+				ret.Errors = append(ret.Errors, cycleErrors(scanner, include)...)
+			}
+			ret.Includes = append(ret.Includes, include)
+			inRecipe = false
 			scanner.Scan()
-		case strings.HasPrefix(scanner.Text(), "."):
-			if matches := reFindSpecialTarget.FindStringSubmatch(scanner.Text()); matches != nil {
-				// Treat special targets like .PHONY or .DEFAULT_GOAL as rules, not variables
-				specialRule := Rule{
-					Target:       strings.TrimSpace(matches[1]),
-					Dependencies: strings.Fields(strings.TrimSpace(matches[2])),
-					Body:         nil,
-					FileName:     filepath,
-					LineNumber:   scanner.LineNumber,
+		case ret.Dialect == BSD && isBSDDirective(scanner.Text()):
+			scanner.mark(CSTDirective, nil)
+			logger.Debug(fmt.Sprintf("Skipping BSD make directive %q on line %d", strings.TrimSpace(scanner.Text()), scanner.LineNumber))
+			scanner.Scan()
+		case isConditionalDirective(scanner.Text()):
+			scanner.mark(CSTConditional, nil)
+			if parseErr := conditionals.handle(scanner.Text(), scanner.Range()); parseErr != nil {
+				ret.Errors = append(ret.Errors, *parseErr)
+			}
+			scanner.Scan()
+		case isDefine(scanner.Text()):
+			// the define block is consumed as a whole, so that lines in it
+			// are never mistaken for rules
+			variable, parseErr := parseDefine(scanner)
+			if parseErr != nil {
+				ret.Errors = append(ret.Errors, *parseErr)
+			}
+			variable.Conditions = conditionals.scope()
+			variable.Doc = docFor(pendingDoc, variable.LineNumber)
+			ret.Variables = append(ret.Variables, variable)
+			inRecipe = false
+		case isInclude(scanner.Text()):
+			scanner.mark(CSTInclude, nil)
+			include := parseInclude(scanner.Text(), scanner.Range())
+			include.Conditions = conditionals.scope()
+			if includes != nil {
+				if err := includes.follow(&include, ret, conditionals); err != nil {
+					return err
 				}
-				ret.Rules = append(ret.Rules, specialRule)
+				ret.Errors = append(ret.Errors, cycleErrors(scanner, include)...)
 			}
+			ret.Includes = append(ret.Includes, include)
+			inRecipe = false
+			scanner.Scan()
+		case isExport(scanner.Text()):
+			scanner.mark(CSTDirective, nil)
+			export := parseExport(scanner)
+			export.Conditions = conditionals.scope()
+			ret.Exports = append(ret.Exports, export)
+			inRecipe = false
+			scanner.Scan()
+		case isUndefine(scanner.Text()):
+			scanner.mark(CSTDirective, nil)
+			undefine := parseUndefine(scanner)
+			undefine.Conditions = conditionals.scope()
+			ret.Undefines = append(ret.Undefines, undefine)
+			inRecipe = false
+			scanner.Scan()
+		case isVPath(scanner.Text()):
+			scanner.mark(CSTDirective, nil)
+			vpath := parseVPath(scanner)
+			vpath.Conditions = conditionals.scope()
+			ret.VPaths = append(ret.VPaths, vpath)
+			inRecipe = false
+			scanner.Scan()
+		case reFindSpecialTarget.MatchString(scanner.Text()):
+			// Treat special targets like .PHONY or .DEFAULT_GOAL as rules, not
+			// variables. Other lines starting with a dot, like suffix rules,
+			// are parsed as rules or variables below. Special targets like
+			// .DEFAULT or BSD's .END can have a recipe like any other rule.
+			scanner.mark(CSTRule, nil)
+			ruleNode = scanner.node
+			line, comment := splitTrailingComment(scanner, scanner.Text(), "")
+			matches := reFindSpecialTarget.FindStringSubmatch(line)
+			rawDeps := matches[2]
+			var body []string
+			var recipe RecipeLineList
+			if idx := indexOutsideReferences(rawDeps, ";"); idx != -1 {
+				inlineRecipe := strings.TrimSpace(rawDeps[idx+1:])
+				rawDeps = rawDeps[:idx]
+				body = append(body, inlineRecipe)
+				recipe = append(recipe, parseRecipeLine(inlineRecipe, scanner.Range()))
+			}
+			ret.Rules = append(ret.Rules, Rule{
+				Target:          strings.TrimSpace(matches[1]),
+				Targets:         []string{strings.TrimSpace(matches[1])},
+				Dependencies:    strings.Fields(rawDeps),
+				Body:            body,
+				Recipe:          recipe,
+				FileName:        scanner.FileName,
+				LineNumber:      scanner.LineNumber,
+				Range:           scanner.Range(),
+				Conditions:      conditionals.scope(),
+				Doc:             docFor(pendingDoc, scanner.LineNumber),
+				TrailingComment: comment,
+			})
+			if comment != nil {
+				ret.Comments = append(ret.Comments, *comment)
+			}
+			ret.Settings.applySpecialTarget(strings.TrimSpace(matches[1]), strings.Fields(rawDeps))
+			inRecipe = true
 			scanner.Scan()
 		default:
+			if strings.TrimSpace(scanner.Text()) == "" {
+				scanner.Scan()
+				break
+			}
 			// parse target or variable here, the function advances the scanner
 			// itself to be able to detect rule bodies
+			afterRule := inRecipe && strings.HasPrefix(scanner.Text(), " ")
+			tabIndented := strings.HasPrefix(scanner.Text(), "\t")
+			node := scanner.node
 			ruleOrVariable, parseError := parseRuleOrVariable(scanner)
-			if parseError != nil {
-				return ret, parseError
+			inRecipe = false
+			if parseErr, ok := parseError.(*ParseError); ok {
+				// an indented line most likely was meant to be part of a
+				// recipe
+				switch {
+				case afterRule:
+					parseErr.Message = "recipe line indented with spaces"
+				case tabIndented:
+					parseErr.Message = "recipe line without a preceding rule"
+				}
+				// the malformed line is skipped, parsing goes on with the
+				// next one
+				markNode(node, CSTUnknown)
+				ret.Errors = append(ret.Errors, *parseErr)
+				ret.Diagnostics = append(ret.Diagnostics, Diagnostic{
+					Reason:     parseErr.Message,
+					Text:       strings.TrimSpace(parseErr.Snippet),
+					FileName:   parseErr.FileName,
+					LineNumber: parseErr.LineNumber,
+					Range:      parseErr.Range,
+				})
+			} else if parseError != nil {
+				return parseError
 			}
 			switch v := ruleOrVariable.(type) {
+			case nil:
+				if parseError == nil {
+					markNode(node, CSTExpansion)
+				}
 			case Rule:
+				markNode(node, CSTRule)
+				ruleNode = node
+				v.Conditions = conditionals.scope()
+				v.Doc = docFor(pendingDoc, v.LineNumber)
+				if v.TrailingComment != nil {
+					ret.Comments = append(ret.Comments, *v.TrailingComment)
+				}
 				ret.Rules = append(ret.Rules, v)
+				inRecipe = true
 			case Variable:
-				ret.Variables = append(ret.Variables, v)
+				markNode(node, CSTVariable)
+				v.Conditions = conditionals.scope()
+				v.Doc = docFor(pendingDoc, v.LineNumber)
+				if v.TrailingComment != nil {
+					ret.Comments = append(ret.Comments, *v.TrailingComment)
+				}
+				if v.Operator == "+=" && len(v.Targets) == 0 {
+					v.SimplyExpanded = ret.Variables.simplyExpanded(v.Name)
+				}
+				switch {
+				case len(v.Targets) > 0:
+					ret.TargetVariables = append(ret.TargetVariables, v)
+				case v.Name == "VPATH":
+					ret.VPaths = append(ret.VPaths, vpathFromVariable(v))
+					ret.Variables = append(ret.Variables, v)
+				default:
+					ret.Settings.applyVariable(v)
+					ret.Variables = append(ret.Variables, v)
+				}
 			}
 
 		}
 
 		if scanner.Finished {
-			return
+			ret.Errors = append(ret.Errors, conditionals.finish(scanner.endOfFile())...)
+			return nil
 		}
 	}
 }
@@ -139,116 +564,243 @@ func Parse(filepath string) (ret Makefile, err error) {
 // subsequent lines if there is a rule body to parse and returns an interface
 // that is either a Variable or a Rule struct and leaves the scanner in a
 // state where it resides on the first line after the content parsed into the
-// returned struct. The parsing of line details is done via regexing for now
+// returned struct. Lines it can't make sense of are skipped and returned as
+// a *ParseError. The parsing of line details is done via regexing for now
 // since it seems ok as a first pass but will likely have to change later into
 // a proper lexer/parser setup.
-//
-//nolint:unparam // parseRuleOrVariable never returns an error yet, placeholder for future error handling
 func parseRuleOrVariable(scanner *MakefileScanner) (ret interface{}, err error) {
-	line := scanner.Text()
+	// outside of a recipe, leading whitespace carries no meaning, e.g. for
+	// indented variable assignments within conditionals
+	rawLine := strings.TrimLeft(scanner.Text(), " \t")
+	line, comment := splitTrailingComment(scanner, rawLine, "")
 
-	if matches := reFindSimpleVariable.FindStringSubmatch(line); matches != nil {
+	// assignments can carry modifiers, e.g. "override CFLAGS += -O2"
+	assignment, modifiers := line, []string(nil)
+	if matches := reFindVariableModifiers.FindStringSubmatch(line); matches != nil {
+		assignment, modifiers = matches[2], strings.Fields(matches[1])
+	}
+
+	if matches := reFindSimpleVariable.FindStringSubmatch(assignment); matches != nil {
 		ret = Variable{
-			Name:           strings.TrimSpace(matches[1]),
-			Assignment:     strings.TrimSpace(matches[2]),
-			SimplyExpanded: true,
-			FileName:       scanner.FileHandle.Name(),
-			LineNumber:     scanner.LineNumber,
+			Name:            strings.TrimSpace(matches[1]),
+			Operator:        matches[2],
+			Assignment:      strings.TrimSpace(matches[3]),
+			Expression:      ParseExpression(strings.TrimSpace(matches[3])),
+			SimplyExpanded:  true,
+			Override:        slices.Contains(modifiers, "override"),
+			Exported:        slices.Contains(modifiers, "export"),
+			Private:         slices.Contains(modifiers, "private"),
+			FileName:        scanner.FileName,
+			LineNumber:      scanner.LineNumber,
+			Range:           scanner.Range(),
+			TrailingComment: comment,
 		}
 		scanner.Scan()
 		return
 	}
 
-	if matches := reFindExpandedVariable.FindStringSubmatch(line); matches != nil {
+	if matches := reFindExpandedVariable.FindStringSubmatch(assignment); matches != nil {
 		ret = Variable{
-			Name:           strings.TrimSpace(matches[1]),
-			Assignment:     strings.TrimSpace(matches[2]),
-			SimplyExpanded: false,
-			FileName:       scanner.FileHandle.Name(),
-			LineNumber:     scanner.LineNumber,
+			Name:            strings.TrimSpace(matches[1]),
+			Operator:        "=",
+			Assignment:      strings.TrimSpace(matches[2]),
+			Expression:      ParseExpression(strings.TrimSpace(matches[2])),
+			SimplyExpanded:  false,
+			Override:        slices.Contains(modifiers, "override"),
+			Exported:        slices.Contains(modifiers, "export"),
+			Private:         slices.Contains(modifiers, "private"),
+			FileName:        scanner.FileName,
+			LineNumber:      scanner.LineNumber,
+			Range:           scanner.Range(),
+			TrailingComment: comment,
 		}
 		scanner.Scan()
 		return
 	}
-	if matches := reFindOtherVariable.FindStringSubmatch(line); matches != nil {
+	if matches := reFindOtherVariable.FindStringSubmatch(assignment); matches != nil {
 		op := strings.TrimSpace(matches[2])
-		isSimple := false // Default to recursive/false
-
-		switch op {
-		case "!=":
-			// Shell assignment is immediate, like simple expansion.
-			isSimple = true
-		case "?=":
-			// Conditional assignment is recursive, just like '='.
-			isSimple = false
-		case "+=":
-			// Append ('+=') inherits its expansion behavior. If the variable was
-			// undefined, '+=' acts like '=' (recursive). Since this parser doesn't
-			// track variable history, we default to recursive (false) as the
-			// safest and most common-case behavior.
-			isSimple = false
+
+		ret = Variable{
+			Name:            strings.TrimSpace(matches[1]),
+			Operator:        op,
+			Assignment:      strings.TrimSpace(matches[3]), // Use index 3 for value
+			Expression:      ParseExpression(strings.TrimSpace(matches[3])),
+			SimplyExpanded:  isSimplyExpanded(op),
+			Override:        slices.Contains(modifiers, "override"),
+			Exported:        slices.Contains(modifiers, "export"),
+			Private:         slices.Contains(modifiers, "private"),
+			FileName:        scanner.FileName,
+			LineNumber:      scanner.LineNumber,
+			Range:           scanner.Range(),
+			TrailingComment: comment,
 		}
+		scanner.Scan()
+		return
+	}
 
+	if matches := reFindTargetVariable.FindStringSubmatch(line); matches != nil {
+		modifiers := strings.Fields(matches[2])
 		ret = Variable{
-			Name:           strings.TrimSpace(matches[1]),
-			Assignment:     strings.TrimSpace(matches[3]), // Use index 3 for value
-			SimplyExpanded: isSimple,
-			FileName:       scanner.FileHandle.Name(),
-			LineNumber:     scanner.LineNumber,
+			Name:            matches[3],
+			Operator:        matches[4],
+			Assignment:      strings.TrimSpace(matches[5]),
+			Expression:      ParseExpression(strings.TrimSpace(matches[5])),
+			SimplyExpanded:  isSimplyExpanded(matches[4]),
+			Override:        slices.Contains(modifiers, "override"),
+			Exported:        slices.Contains(modifiers, "export"),
+			Private:         slices.Contains(modifiers, "private"),
+			Targets:         strings.Fields(matches[1]),
+			FileName:        scanner.FileName,
+			LineNumber:      scanner.LineNumber,
+			Range:           scanner.Range(),
+			TrailingComment: comment,
 		}
 		scanner.Scan()
 		return
 	}
 
+	// a ";" starting an inline recipe also ends the search for a comment
+	line, comment = splitTrailingComment(scanner, rawLine, ";")
 	if matches := reFindRule.FindStringSubmatch(line); matches != nil {
-		beginLineNumber := scanner.LineNumber - 1
+		beginLineNumber := scanner.LineNumber
+		ruleRange := scanner.Range()
 		scanner.Scan()
 
+		// A second colon right after the first one makes a double-colon rule
+		rawDeps := strings.TrimSpace(matches[3])
+		doubleColon := strings.HasPrefix(rawDeps, ":")
+		if doubleColon {
+			rawDeps = strings.TrimSpace(rawDeps[1:])
+		}
+
 		// Handle inline recipe syntax: target: deps ; recipe
-		rawDeps := strings.TrimSpace(matches[2])
 		inlineRecipe := ""
-		if idx := strings.IndexAny(rawDeps, ";"); idx != -1 {
+		if idx := indexOutsideReferences(rawDeps, ";"); idx != -1 {
 			inlineRecipe = strings.TrimSpace(rawDeps[idx+1:])
 			rawDeps = strings.TrimSpace(rawDeps[:idx])
 		}
 
-		// Clean up dependencies (space-separated)
-		deps := []string{}
-		for _, d := range strings.Fields(rawDeps) {
-			if d != "" {
-				deps = append(deps, d)
-			}
+		// Static pattern rules have a second colon: targets: pattern: deps
+		targetPattern := ""
+		if idx := indexOutsideReferences(rawDeps, ":"); idx != -1 {
+			targetPattern = strings.TrimSpace(rawDeps[:idx])
+			rawDeps = rawDeps[idx+1:]
 		}
 
-		// Collect recipe body (inline + tab-indented)
+		// Split normal from order-only dependencies: target: deps | order-only
+		rawOrderOnly := ""
+		if idx := indexOutsideReferences(rawDeps, "|"); idx != -1 {
+			rawOrderOnly = rawDeps[idx+1:]
+			rawDeps = rawDeps[:idx]
+		}
+		deps := strings.Fields(rawDeps)
+		orderOnlyDeps := strings.Fields(rawOrderOnly)
+
+		// The recipe lines following the rule are collected by the caller,
+		// which knows the current recipe prefix
 		ruleBody := []string{}
+		var recipe RecipeLineList
 		if inlineRecipe != "" {
 			ruleBody = append(ruleBody, inlineRecipe)
+			recipe = append(recipe, parseRecipeLine(inlineRecipe, ruleRange))
 		}
 
-		// collect tab-indented body lines after the rule
-		for bodyMatches := reFindRuleBody.FindStringSubmatch(scanner.Text()); bodyMatches != nil; bodyMatches = reFindRuleBody.FindStringSubmatch(scanner.Text()) {
-			ruleBody = append(ruleBody, strings.TrimSpace(bodyMatches[1]))
-			scanner.Scan()
-		}
-
-		ret = Rule{
-			Target:       strings.TrimSpace(matches[1]),
-			Dependencies: deps,
-			Body:         ruleBody,
-			FileName:     scanner.FileHandle.Name(),
-			LineNumber:   beginLineNumber,
+		rule := Rule{
+			Target:                strings.TrimSpace(matches[1]),
+			Targets:               strings.Fields(matches[1]),
+			Grouped:               matches[2] == "&",
+			Dependencies:          deps,
+			OrderOnlyDependencies: orderOnlyDeps,
+			DoubleColon:           doubleColon,
+			Body:                  ruleBody,
+			Recipe:                recipe,
+			FileName:              scanner.FileName,
+			LineNumber:            beginLineNumber,
+			Range:                 ruleRange,
+			TrailingComment:       comment,
 		}
+		classifyRule(&rule, targetPattern)
+		ret = rule
 		return
 	}
 
-	// Fallback: unrecognized line
-	if strings.TrimSpace(line) != "" {
-		if strings.Contains(line, ":") && strings.Contains(line, "=") {
-			logger.Debug(fmt.Sprintf("Ambiguous line detected: %q (could be variable or rule)", line))
-		}
-		logger.Debug(fmt.Sprintf("Unable to match line '%s' to a Rule or Variable", line))
+	// Fallback: unrecognized line. Lines only made of a function call like
+	// $(error ...) or $(eval ...) are valid, but there is nothing to parse.
+	if strings.TrimSpace(line) == "" || isExpansion(line) {
+		scanner.Scan()
+		return
+	}
+	reason := "neither a rule nor a variable"
+	if strings.Contains(line, ":") && strings.Contains(line, "=") {
+		logger.Debug(fmt.Sprintf("Ambiguous line detected: %q (could be variable or rule)", line))
+		reason = "ambiguous rule/variable"
 	}
+	logger.Debug(fmt.Sprintf("Unable to match line '%s' to a Rule or Variable", line))
+	err = newParseError(scanner, reason)
 	scanner.Scan()
 	return
 }
+
+// isSimplyExpanded reports whether a variable assigned with the given
+// operator is expanded immediately rather than recursively
+func isSimplyExpanded(op string) bool {
+	switch op {
+	case ":=", "::=", ":::=":
+		return true
+	case "!=":
+		// Shell assignment is immediate, like simple expansion.
+		return true
+	case "?=":
+		// Conditional assignment is recursive, just like '='.
+		return false
+	case "+=":
+		// Append ('+=') inherits its expansion behavior. If the variable was
+		// undefined, '+=' acts like '=' (recursive), which is the default
+		// here. The flavor of an earlier assignment is applied once the
+		// variable is added to the Makefile, see VariableList.simplyExpanded.
+		return false
+	}
+	return false
+}
+
+// isExpansion reports whether the line consists of a single variable
+// reference or function call, like "$(info building)"
+func isExpansion(line string) bool {
+	line = strings.TrimSpace(line)
+	if len(line) < 2 || line[0] != '$' {
+		return false
+	}
+	var closing byte
+	switch line[1] {
+	case '(':
+		closing = ')'
+	case '{':
+		closing = '}'
+	default:
+		return false
+	}
+	// like make, count the nesting of the delimiter the reference starts
+	// with, so bare parentheses like in "$(error see (README))" are kept
+	// in the argument. The reference has to span the whole line.
+	depth := 0
+	for i := 1; i < len(line); i++ {
+		switch line[i] {
+		case line[1]:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return i == len(line)-1
+			}
+		}
+	}
+	return false
+}
+
+// markNode sets the kind of a node of the concrete syntax tree, which is nil
+// for scanners not reading a tree
+func markNode(node *CSTNode, kind CSTKind) {
+	if node != nil {
+		node.Kind = kind
+	}
+}
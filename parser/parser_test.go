@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"log"
 	"os"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/checkmake/checkmake/logger"
 	"github.com/stretchr/testify/assert"
@@ -36,6 +38,14 @@ func TestParseSimpleMakefile(t *testing.T) {
 	assert.Equal(t, ret.Rules[3].Dependencies, []string{"foo"})
 	assert.Equal(t, ret.Rules[3].FileName, "../fixtures/simple.make")
 
+	// the recipe of test is indented with spaces, which make rejects
+	assert.Equal(t, ret.Rules[4].Target, "test")
+	assert.Empty(t, ret.Rules[4].Body)
+	require.Len(t, ret.Diagnostics, 1)
+	assert.Equal(t, "recipe line indented with spaces", ret.Diagnostics[0].Reason)
+	assert.Equal(t, "@echo lolnah", ret.Diagnostics[0].Text)
+	assert.Equal(t, 19, ret.Diagnostics[0].LineNumber)
+
 	assert.Equal(t, ".PHONY", ret.Rules[5].Target)
 	assert.Equal(t, []string{"all", "clean", "test"}, ret.Rules[5].Dependencies)
 	assert.Equal(t, "../fixtures/simple.make", ret.Rules[5].FileName)
@@ -259,7 +269,6 @@ func TestParse_RuleWithEqualsInPrereq(t *testing.T) {
 	t.Parallel()
 	makefile := `
 target: prerequisite = value
-	@echo "rule with equals"
 `
 	tmp := writeTempMakefile(t, makefile)
 	defer os.Remove(tmp)
@@ -267,12 +276,150 @@ target: prerequisite = value
 	ret, err := Parse(tmp)
 	require.NoError(t, err)
 
-	require.Len(t, ret.Rules, 1)
-	assert.Equal(t, "target", ret.Rules[0].Target)
-	assert.Equal(t, []string{"prerequisite", "=", "value"}, ret.Rules[0].Dependencies)
+	// an assignment after the colon is a target-specific variable, not a rule
+	assert.Empty(t, ret.Rules)
+	assert.Empty(t, ret.Variables)
+	require.Len(t, ret.TargetVariables, 1)
+	assert.Equal(t, "prerequisite", ret.TargetVariables[0].Name)
+	assert.Equal(t, "=", ret.TargetVariables[0].Operator)
+	assert.Equal(t, "value", ret.TargetVariables[0].Assignment)
+	assert.Equal(t, []string{"target"}, ret.TargetVariables[0].Targets)
+}
+
+func TestParse_MultipleAndGroupedTargets(t *testing.T) {
+	t.Parallel()
+	makefile := `
+clean distclean mrproper:
+	rm -rf build
+parser.c parser.h &: parser.y
+	bison --defines=parser.h -o parser.c parser.y
+.PHONY: clean distclean mrproper
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 3)
+
+	assert.Equal(t, "clean distclean mrproper", ret.Rules[0].Target)
+	assert.Equal(t, []string{"clean", "distclean", "mrproper"}, ret.Rules[0].Targets)
+	assert.False(t, ret.Rules[0].Grouped)
+
+	assert.Equal(t, "parser.c parser.h", ret.Rules[1].Target)
+	assert.Equal(t, []string{"parser.c", "parser.h"}, ret.Rules[1].Targets)
+	assert.True(t, ret.Rules[1].Grouped)
+	assert.Equal(t, []string{"parser.y"}, ret.Rules[1].Dependencies)
+	assert.Len(t, ret.Rules[1].Body, 1)
+
+	assert.Equal(t, []string{".PHONY"}, ret.Rules[2].Targets)
+}
+
+func TestRule_TargetNames(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"a", "b"}, Rule{Target: "a b"}.TargetNames())
+	assert.Equal(t, []string{"c"}, Rule{Target: "a b", Targets: []string{"c"}}.TargetNames())
+	assert.Empty(t, Rule{}.TargetNames())
+}
+
+func TestParse_OrderOnlyDependencies(t *testing.T) {
+	t.Parallel()
+	makefile := `
+build/app.o: app.c app.h | build
+	$(CC) -c -o $@ $<
+
+install: | build/app
+	cp build/app /usr/local/bin
 
-	require.Len(t, ret.Rules[0].Body, 1)
-	assert.Equal(t, "@echo \"rule with equals\"", ret.Rules[0].Body[0])
+stamp: ; touch $@ | cat
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 3)
+
+	assert.Equal(t, []string{"app.c", "app.h"}, ret.Rules[0].Dependencies)
+	assert.Equal(t, []string{"build"}, ret.Rules[0].OrderOnlyDependencies)
+
+	assert.Empty(t, ret.Rules[1].Dependencies)
+	assert.Equal(t, []string{"build/app"}, ret.Rules[1].OrderOnlyDependencies)
+
+	// a pipe within an inline recipe is not an order-only separator
+	assert.Empty(t, ret.Rules[2].OrderOnlyDependencies)
+	assert.Equal(t, []string{"touch $@ | cat"}, ret.Rules[2].Body)
+}
+
+func TestParse_DoubleColonRules(t *testing.T) {
+	t.Parallel()
+	makefile := `
+install:: bin
+	cp bin /usr/local/bin
+install:: docs | build
+	cp docs /usr/local/share
+clean:
+	rm -rf build
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 3)
+
+	for _, rule := range ret.Rules[:2] {
+		assert.Equal(t, "install", rule.Target)
+		assert.True(t, rule.DoubleColon)
+		assert.Len(t, rule.Body, 1)
+	}
+	assert.Equal(t, []string{"bin"}, ret.Rules[0].Dependencies)
+	assert.Equal(t, []string{"docs"}, ret.Rules[1].Dependencies)
+	assert.Equal(t, []string{"build"}, ret.Rules[1].OrderOnlyDependencies)
+
+	assert.Equal(t, "clean", ret.Rules[2].Target)
+	assert.False(t, ret.Rules[2].DoubleColon)
+}
+
+func TestParse_TargetSpecificVariables(t *testing.T) {
+	t.Parallel()
+	makefile := `CFLAGS := -O2
+
+debug release: CFLAGS += -g
+%.o: private LDFLAGS := -s
+install: override export PREFIX ?= /usr/local
+
+debug:
+	$(CC) $(CFLAGS) -o app main.c
+
+%.o: %.c
+	$(CC) -c $<
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+
+	require.Len(t, ret.Variables, 1)
+	require.Len(t, ret.TargetVariables, 3)
+	require.Len(t, ret.Rules, 2)
+
+	debug := ret.TargetVariables[0]
+	assert.Equal(t, "CFLAGS", debug.Name)
+	assert.Equal(t, "+=", debug.Operator)
+	assert.Equal(t, "-g", debug.Assignment)
+	assert.Equal(t, []string{"debug", "release"}, debug.Targets)
+	assert.Equal(t, 3, debug.LineNumber)
+	assert.False(t, debug.SimplyExpanded)
+
+	pattern := ret.TargetVariables[1]
+	assert.Equal(t, "LDFLAGS", pattern.Name)
+	assert.Equal(t, []string{"%.o"}, pattern.Targets)
+	assert.True(t, pattern.Private)
+	assert.True(t, pattern.SimplyExpanded)
+	assert.False(t, pattern.Override)
+
+	install := ret.TargetVariables[2]
+	assert.Equal(t, "PREFIX", install.Name)
+	assert.Equal(t, "?=", install.Operator)
+	assert.Equal(t, "/usr/local", install.Assignment)
+	assert.True(t, install.Override)
+	assert.True(t, install.Exported)
+	assert.False(t, install.Private)
+
+	assert.Equal(t, "debug", ret.Rules[0].Target)
+	assert.Equal(t, VariableList{debug}, ret.Rules[0].TargetVariables)
+	assert.Equal(t, "%.o", ret.Rules[1].Target)
+	assert.Equal(t, VariableList{pattern}, ret.Rules[1].TargetVariables)
 }
 
 func TestParse_OtherVariableAssignments(t *testing.T) {
@@ -335,3 +482,361 @@ SHELL != echo hi
 	assert.Contains(t, varNames, "APPEND")
 	assert.Contains(t, varNames, "SHELL")
 }
+
+func TestParse_Conditionals(t *testing.T) {
+	t.Parallel()
+	makefile := `
+ifeq ($(OS),Windows_NT)
+EXT := .exe
+build:
+	@echo windows
+else ifdef CROSS
+EXT := .cross
+else
+EXT :=
+build:
+	@echo unix
+ifndef CC
+  CC := gcc
+endif
+endif
+`
+	tmp := writeTempMakefile(t, makefile)
+	defer os.Remove(tmp)
+
+	ret, err := Parse(tmp)
+	require.NoError(t, err)
+
+	require.Len(t, ret.Conditionals, 1)
+	cond := ret.Conditionals[0]
+	assert.Equal(t, 2, cond.LineNumber)
+	assert.Equal(t, 15, cond.EndLineNumber)
+	require.Len(t, cond.Branches, 3)
+
+	assert.Equal(t, "ifeq", cond.Branches[0].Directive)
+	assert.Equal(t, "($(OS),Windows_NT)", cond.Branches[0].Condition)
+	assert.Equal(t, 2, cond.Branches[0].LineNumber)
+	require.Len(t, cond.Branches[0].Rules, 1)
+	assert.Equal(t, "build", cond.Branches[0].Rules[0].Target)
+	assert.Equal(t, []string{"@echo windows"}, cond.Branches[0].Rules[0].Body)
+	require.Len(t, cond.Branches[0].Variables, 1)
+	assert.Equal(t, "EXT", cond.Branches[0].Variables[0].Name)
+
+	assert.Equal(t, "ifdef", cond.Branches[1].Directive)
+	assert.Equal(t, "CROSS", cond.Branches[1].Condition)
+	assert.Empty(t, cond.Branches[1].Rules)
+	assert.Len(t, cond.Branches[1].Variables, 1)
+
+	assert.Equal(t, "", cond.Branches[2].Directive)
+	assert.Equal(t, 8, cond.Branches[2].LineNumber)
+	require.Len(t, cond.Branches[2].Rules, 1)
+	assert.Equal(t, []string{"@echo unix"}, cond.Branches[2].Rules[0].Body)
+	require.Len(t, cond.Branches[2].Conditionals, 1)
+
+	nested := cond.Branches[2].Conditionals[0]
+	assert.Equal(t, "ifndef", nested.Branches[0].Directive)
+	assert.Equal(t, "CC", nested.Branches[0].Condition)
+	require.Len(t, nested.Branches[0].Variables, 1)
+	assert.Equal(t, "CC", nested.Branches[0].Variables[0].Name)
+	assert.Equal(t, []ConditionalBranchRef{{0, 2}, {1, 0}}, nested.Branches[0].Variables[0].Conditions)
+
+	require.Len(t, ret.Rules, 2)
+	assert.True(t, MutuallyExclusive(ret.Rules[0].Conditions, ret.Rules[1].Conditions))
+}
+
+func TestParse_ConditionalWithinRecipe(t *testing.T) {
+	t.Parallel()
+	makefile := `
+all:
+ifdef VERBOSE
+	@echo verbose
+else
+	@echo quiet
+endif
+
+test:
+	@echo test
+`
+	tmp := writeTempMakefile(t, makefile)
+	defer os.Remove(tmp)
+
+	ret, err := Parse(tmp)
+	require.NoError(t, err)
+
+	require.Len(t, ret.Rules, 2)
+	assert.Equal(t, "all", ret.Rules[0].Target)
+	assert.Equal(t, []string{"@echo verbose", "@echo quiet"}, ret.Rules[0].Body)
+	assert.Empty(t, ret.Rules[0].Conditions)
+	assert.Equal(t, []string{"@echo test"}, ret.Rules[1].Body)
+	require.Len(t, ret.Conditionals, 1)
+	assert.Len(t, ret.Conditionals[0].Branches, 2)
+}
+
+func TestMutuallyExclusive(t *testing.T) {
+	t.Parallel()
+	assert.False(t, MutuallyExclusive(nil, nil))
+	assert.False(t, MutuallyExclusive(nil, []ConditionalBranchRef{{0, 1}}))
+	assert.False(t, MutuallyExclusive([]ConditionalBranchRef{{0, 0}}, []ConditionalBranchRef{{0, 0}}))
+	assert.True(t, MutuallyExclusive([]ConditionalBranchRef{{0, 0}}, []ConditionalBranchRef{{0, 1}}))
+	assert.False(t, MutuallyExclusive([]ConditionalBranchRef{{0, 0}}, []ConditionalBranchRef{{1, 1}}))
+	assert.True(t, MutuallyExclusive(
+		[]ConditionalBranchRef{{0, 0}, {1, 0}},
+		[]ConditionalBranchRef{{0, 0}, {1, 1}},
+	))
+}
+
+func TestParse_DefineBlocks(t *testing.T) {
+	t.Parallel()
+	makefile := `
+define HELP_TEXT
+usage: make <target>
+  build: builds the project
+endef
+
+override define RUN :=
+@echo running
+$(call run,$(1))
+endef
+
+export define NESTED ?=
+define INNER
+inner: value
+endef
+endef # trailing comment
+
+all:
+	@echo $(HELP_TEXT)
+`
+	tmp := writeTempMakefile(t, makefile)
+	defer os.Remove(tmp)
+
+	ret, err := Parse(tmp)
+	require.NoError(t, err)
+
+	require.Len(t, ret.Rules, 1, "lines within define blocks must not be parsed as rules")
+	assert.Equal(t, "all", ret.Rules[0].Target)
+	assert.Equal(t, []string{"@echo $(HELP_TEXT)"}, ret.Rules[0].Body)
+
+	require.Len(t, ret.Variables, 3)
+	assert.Equal(t, "HELP_TEXT", ret.Variables[0].Name)
+	assert.Equal(t, "=", ret.Variables[0].Operator)
+	assert.False(t, ret.Variables[0].SimplyExpanded)
+	assert.Equal(t, "usage: make <target>\n  build: builds the project", ret.Variables[0].Assignment)
+	assert.Equal(t, 2, ret.Variables[0].LineNumber)
+
+	assert.Equal(t, "RUN", ret.Variables[1].Name)
+	assert.Equal(t, ":=", ret.Variables[1].Operator)
+	assert.True(t, ret.Variables[1].SimplyExpanded)
+	assert.Equal(t, "@echo running\n$(call run,$(1))", ret.Variables[1].Assignment)
+
+	assert.Equal(t, "NESTED", ret.Variables[2].Name)
+	assert.Equal(t, "?=", ret.Variables[2].Operator)
+	assert.Equal(t, "define INNER\ninner: value\nendef", ret.Variables[2].Assignment)
+}
+
+func TestParse_UnterminatedDefine(t *testing.T) {
+	t.Parallel()
+	makefile := `define BROKEN
+foo: bar
+`
+	tmp := writeTempMakefile(t, makefile)
+	defer os.Remove(tmp)
+
+	ret, err := Parse(tmp)
+	require.NoError(t, err)
+
+	assert.Empty(t, ret.Rules)
+	require.Len(t, ret.Variables, 1)
+	assert.Equal(t, "foo: bar", ret.Variables[0].Assignment)
+}
+
+func TestParse_ContinuationLines(t *testing.T) {
+	t.Parallel()
+	ret, err := Parse("../fixtures/continuations.make")
+	require.NoError(t, err)
+
+	require.Len(t, ret.Variables, 2)
+	assert.Equal(t, "SRCS", ret.Variables[0].Name)
+	assert.Equal(t, "a.c b.c c.c", ret.Variables[0].Assignment)
+	assert.Equal(t, `C:\\`, ret.Variables[1].Assignment)
+
+	require.Len(t, ret.Rules, 1)
+	assert.Equal(t, "all", ret.Rules[0].Target)
+	assert.Equal(t, []string{"one", "two"}, ret.Rules[0].Dependencies)
+	assert.Equal(t, 7, ret.Rules[0].LineNumber)
+	assert.Equal(t, []string{
+		"@for f in $(SRCS); do \\\n\techo $$f; \\\ndone",
+		"@echo done",
+	}, ret.Rules[0].Body, "recipe lines are counted as logical lines")
+}
+
+func TestParse_Ranges(t *testing.T) {
+	t.Parallel()
+	makefile := `# header
+  VERSION := 1.0
+ifdef DEBUG
+build: dep \
+       other
+	@echo one
+	@echo two
+endif
+include common.mk
+.PHONY: build
+`
+	tmp := writeTempMakefile(t, makefile)
+	defer os.Remove(tmp)
+
+	ret, err := Parse(tmp)
+	require.NoError(t, err)
+
+	require.Len(t, ret.Variables, 1)
+	assert.Equal(t, 2, ret.Variables[0].LineNumber)
+	assert.Equal(t, Range{FileName: tmp, Start: Position{2, 3}, End: Position{2, 17}}, ret.Variables[0].Range)
+
+	require.Len(t, ret.Rules, 2)
+	assert.Equal(t, 4, ret.Rules[0].LineNumber)
+	assert.Equal(t, Range{FileName: tmp, Start: Position{4, 1}, End: Position{7, 11}}, ret.Rules[0].Range)
+	assert.Equal(t, 10, ret.Rules[1].LineNumber)
+	assert.Equal(t, Range{FileName: tmp, Start: Position{10, 1}, End: Position{10, 14}}, ret.Rules[1].Range)
+
+	require.Len(t, ret.Conditionals, 1)
+	cond := ret.Conditionals[0]
+	assert.Equal(t, 3, cond.LineNumber)
+	assert.Equal(t, 8, cond.EndLineNumber)
+	assert.Equal(t, Range{FileName: tmp, Start: Position{3, 1}, End: Position{8, 6}}, cond.Range)
+	assert.Equal(t, Range{FileName: tmp, Start: Position{3, 1}, End: Position{8, 1}}, cond.Branches[0].Range)
+
+	require.Len(t, ret.Includes, 1)
+	assert.Equal(t, 9, ret.Includes[0].LineNumber)
+	assert.Equal(t, Range{FileName: tmp, Start: Position{9, 1}, End: Position{9, 18}}, ret.Includes[0].Range)
+}
+
+func TestParse_SpecialTargetAndVariableLineNumbers(t *testing.T) {
+	t.Parallel()
+	ret, err := Parse("../fixtures/simple.make")
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, ret.Variables[0].LineNumber)
+	assert.Equal(t, 4, ret.Variables[1].LineNumber)
+	assert.Equal(t, 6, ret.Rules[0].LineNumber)
+	assert.Equal(t, 21, ret.Rules[5].LineNumber)
+	assert.Equal(t, 23, ret.Rules[6].LineNumber)
+}
+
+func TestParseReader(t *testing.T) {
+	t.Parallel()
+	makefile := `
+VERSION := 1.0
+all: build
+	@echo all
+`
+	ret, err := ParseReader("generated.mk", strings.NewReader(makefile))
+	require.NoError(t, err)
+
+	assert.Equal(t, "generated.mk", ret.FileName)
+	require.Len(t, ret.Rules, 1)
+	assert.Equal(t, "all", ret.Rules[0].Target)
+	assert.Equal(t, "generated.mk", ret.Rules[0].FileName)
+	assert.Equal(t, 3, ret.Rules[0].LineNumber)
+	require.Len(t, ret.Variables, 1)
+	assert.Equal(t, "generated.mk", ret.Variables[0].FileName)
+	assert.Equal(t, "generated.mk", ret.Variables[0].Range.FileName)
+}
+
+func TestParseFS(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"project/Makefile":   {Data: []byte("include mk/*.mk\n.PHONY: all\nall: clean\n")},
+		"project/mk/a.mk":    {Data: []byte("clean:\n\trm -rf build\n")},
+		"project/mk/b.mk":    {Data: []byte("include ../Makefile\nVERSION := 1.0\n")},
+		"project/mk/not.txt": {Data: []byte("ignored: file\n")},
+	}
+
+	ret, err := ParseFS(fsys, "project/Makefile")
+	require.NoError(t, err)
+	assert.Equal(t, "project/Makefile", ret.FileName)
+	assert.Len(t, ret.Rules, 2, "includes are not followed by default")
+
+	ret, err = ParseWithOptions("project/Makefile", ParseOptions{FS: fsys, FollowIncludes: true})
+	require.NoError(t, err)
+
+	require.Len(t, ret.Rules, 3)
+	assert.Equal(t, "clean", ret.Rules[0].Target)
+	assert.Equal(t, "project/mk/a.mk", ret.Rules[0].FileName)
+	require.Len(t, ret.Variables, 1)
+	assert.Equal(t, "project/mk/b.mk", ret.Variables[0].FileName)
+	assert.Equal(t, []string{"project/mk/a.mk", "project/mk/b.mk"}, ret.Includes[len(ret.Includes)-1].Resolved)
+
+	_, err = ParseFS(fsys, "project/missing.mk")
+	assert.Error(t, err)
+}
+
+func TestParse_Diagnostics(t *testing.T) {
+	t.Parallel()
+	makefile := `all: build
+	@echo all
+
+build:
+    go build ./...
+
+	@echo stray
+thisisnotarule
+two words = value: more
+$(info building)
+$(eval $(call template,app))
+$(error Please set X (see README))
+${info hi (there) {and} here}
+$(info unbalanced))
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 2)
+	require.Len(t, ret.Diagnostics, 5)
+
+	assert.Equal(t, Diagnostic{
+		Reason:     "recipe line indented with spaces",
+		Text:       "go build ./...",
+		FileName:   "Makefile",
+		LineNumber: 5,
+		Range:      Range{FileName: "Makefile", Start: Position{5, 5}, End: Position{5, 19}},
+	}, ret.Diagnostics[0])
+	assert.Equal(t, "@echo stray", ret.Diagnostics[1].Text)
+	assert.Equal(t, "recipe line without a preceding rule", ret.Diagnostics[1].Reason)
+	assert.Equal(t, "thisisnotarule", ret.Diagnostics[2].Text)
+	assert.Equal(t, "neither a rule nor a variable", ret.Diagnostics[2].Reason)
+	assert.Equal(t, 8, ret.Diagnostics[2].LineNumber)
+	assert.Equal(t, "ambiguous rule/variable", ret.Diagnostics[3].Reason)
+	assert.Equal(t, "$(info unbalanced))", ret.Diagnostics[4].Text)
+}
+
+func TestParse_SpecialTargetRecipes(t *testing.T) {
+	t.Parallel()
+	makefile := `.PHONY: all
+all:
+.DEFAULT:
+	@echo no rule for $@
+	@exit 1
+.END: ; @echo done
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	assert.Empty(t, ret.Diagnostics)
+	require.Len(t, ret.Rules, 4)
+
+	def := ret.Rules[2]
+	assert.Equal(t, ".DEFAULT", def.Target)
+	assert.Equal(t, []string{"@echo no rule for $@", "@exit 1"}, def.Body)
+	require.Len(t, def.Recipe, 2)
+	assert.True(t, def.Recipe[0].Silent)
+	assert.Equal(t, 5, def.Range.End.Line)
+
+	end := ret.Rules[3]
+	assert.Empty(t, end.Dependencies)
+	assert.Equal(t, []string{"@echo done"}, end.Body)
+
+	var children []CSTKind
+	for _, child := range ret.CST.Nodes[2].Children {
+		children = append(children, child.Kind)
+	}
+	assert.Equal(t, []CSTKind{CSTRecipe, CSTRecipe}, children)
+}
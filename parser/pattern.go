@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// RuleKind classifies a rule by how its targets are matched
+type RuleKind int
+
+const (
+	// ExplicitRule is a rule for concrete targets like "app: main.o"
+	ExplicitRule RuleKind = iota
+	// PatternRule is an implicit rule with "%" in its targets like
+	// "%.o: %.c"
+	PatternRule
+	// StaticPatternRule applies a pattern to a list of concrete targets like
+	// "$(OBJS): %.o: %.c"
+	StaticPatternRule
+	// SuffixRule is an old-fashioned implicit rule like ".c.o:"
+	SuffixRule
+)
+
+// String returns the name of the rule kind
+func (k RuleKind) String() string {
+	switch k {
+	case PatternRule:
+		return "pattern"
+	case StaticPatternRule:
+		return "static-pattern"
+	case SuffixRule:
+		return "suffix"
+	}
+	return "explicit"
+}
+
+// classifyRule sets the kind and the stem patterns of a rule. A non-empty
+// targetPattern makes it a static pattern rule. Suffix rules are classified
+// by classifySuffixRules once the known suffixes are.
+func classifyRule(rule *Rule, targetPattern string) {
+	switch {
+	case targetPattern != "":
+		rule.Kind = StaticPatternRule
+		rule.TargetPatterns = []string{targetPattern}
+		rule.PrerequisitePatterns = patternsOf(rule.Dependencies)
+	case len(patternsOf(rule.Targets)) > 0:
+		rule.Kind = PatternRule
+		rule.TargetPatterns = patternsOf(rule.Targets)
+		rule.PrerequisitePatterns = patternsOf(rule.Dependencies)
+	}
+}
+
+// classifySuffixRules turns the explicit rules without prerequisites whose
+// single target is a known suffix (".sh") or two of them (".c.o") into
+// suffix rules. Like make, it uses the suffixes known at the end of the
+// Makefile, so other targets starting with a dot like ".venv" stay explicit.
+func classifySuffixRules(ruleList RuleList, known []string) {
+	for i := range ruleList {
+		rule := &ruleList[i]
+		if rule.Kind != ExplicitRule || len(rule.Targets) != 1 || len(rule.Dependencies) > 0 {
+			continue
+		}
+		source, target, ok := splitSuffixes(rule.Targets[0], known)
+		if !ok {
+			continue
+		}
+		rule.Kind = SuffixRule
+		rule.TargetPatterns = []string{"%" + target}
+		rule.PrerequisitePatterns = []string{"%" + source}
+	}
+}
+
+// splitSuffixes splits the target of a suffix rule into its source suffix
+// and its target suffix, which is empty for a single-suffix rule
+func splitSuffixes(name string, known []string) (source, target string, ok bool) {
+	if slices.Contains(known, name) {
+		return name, "", true
+	}
+	for _, suffix := range known {
+		if rest, found := strings.CutPrefix(name, suffix); found && slices.Contains(known, rest) {
+			return suffix, rest, true
+		}
+	}
+	return "", "", false
+}
+
+// patternsOf returns the words containing a "%" stem placeholder
+func patternsOf(words []string) []string {
+	var ret []string
+	for _, word := range words {
+		if strings.Contains(word, "%") {
+			ret = append(ret, word)
+		}
+	}
+	return ret
+}
+
+// MatchTarget reports whether the rule applies to the given concrete target
+// and returns the stem "%" matched. Explicit rules match their targets with
+// an empty stem. Static pattern rules only match targets they list.
+func (r Rule) MatchTarget(target string) (stem string, ok bool) {
+	if r.Kind == ExplicitRule || r.Kind == StaticPatternRule {
+		found := false
+		for _, t := range r.TargetNames() {
+			if t == target {
+				found = true
+				break
+			}
+		}
+		if !found || r.Kind == ExplicitRule {
+			return "", found
+		}
+	}
+
+	for _, pattern := range r.TargetPatterns {
+		if stem, ok := matchPattern(pattern, target); ok {
+			return stem, true
+		}
+	}
+	return "", false
+}
+
+// matchPattern matches a word against a pattern containing a single "%"
+// and returns the part of the word the "%" stands for
+func matchPattern(pattern, word string) (string, bool) {
+	prefix, suffix, found := strings.Cut(pattern, "%")
+	if !found {
+		return "", pattern == word
+	}
+	if len(word) < len(prefix)+len(suffix) ||
+		!strings.HasPrefix(word, prefix) || !strings.HasSuffix(word, suffix) {
+		return "", false
+	}
+	return word[len(prefix) : len(word)-len(suffix)], true
+}
+
+// indexOutsideReferences returns the index of the first occurrence of any of
+// the chars in s which is not part of a variable reference like $(VAR:a=b),
+// or -1 if there is none
+func indexOutsideReferences(s, chars string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '(' || c == '{':
+			if depth > 0 || (i > 0 && s[i-1] == '$') {
+				depth++
+			}
+		case (c == ')' || c == '}') && depth > 0:
+			depth--
+		case depth == 0 && strings.IndexByte(chars, c) != -1:
+			return i
+		}
+	}
+	return -1
+}
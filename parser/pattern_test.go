@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_RuleKinds(t *testing.T) {
+	t.Parallel()
+	makefile := `OBJS := foo.o bar.o
+
+app: $(OBJS) $(SRCS:.c=.h)
+	$(CC) -o $@ $^
+
+$(OBJS): %.o: %.c | build
+	$(CC) -c $< -o $@
+
+%.tab.c %.tab.h: %.y
+	bison $<
+
+.c.o:
+	$(CC) -c $<
+
+.sh:
+	cp $< $@
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 5)
+
+	app := ret.Rules[0]
+	assert.Equal(t, ExplicitRule, app.Kind)
+	assert.Equal(t, []string{"$(OBJS)", "$(SRCS:.c=.h)"}, app.Dependencies)
+	assert.Empty(t, app.TargetPatterns)
+	assert.Empty(t, app.PrerequisitePatterns)
+
+	static := ret.Rules[1]
+	assert.Equal(t, StaticPatternRule, static.Kind)
+	assert.Equal(t, []string{"$(OBJS)"}, static.Targets)
+	assert.Equal(t, []string{"%.o"}, static.TargetPatterns)
+	assert.Equal(t, []string{"%.c"}, static.Dependencies)
+	assert.Equal(t, []string{"%.c"}, static.PrerequisitePatterns)
+	assert.Equal(t, []string{"build"}, static.OrderOnlyDependencies)
+
+	pattern := ret.Rules[2]
+	assert.Equal(t, PatternRule, pattern.Kind)
+	assert.Equal(t, []string{"%.tab.c", "%.tab.h"}, pattern.TargetPatterns)
+	assert.Equal(t, []string{"%.y"}, pattern.PrerequisitePatterns)
+
+	suffix := ret.Rules[3]
+	assert.Equal(t, SuffixRule, suffix.Kind)
+	assert.Equal(t, ".c.o", suffix.Target)
+	assert.Equal(t, []string{"%.o"}, suffix.TargetPatterns)
+	assert.Equal(t, []string{"%.c"}, suffix.PrerequisitePatterns)
+	assert.Equal(t, []string{"$(CC) -c $<"}, suffix.Body)
+
+	single := ret.Rules[4]
+	assert.Equal(t, SuffixRule, single.Kind)
+	assert.Equal(t, []string{"%"}, single.TargetPatterns)
+	assert.Equal(t, []string{"%.sh"}, single.PrerequisitePatterns)
+}
+
+func TestParse_SuffixRulesNeedKnownSuffixes(t *testing.T) {
+	t.Parallel()
+	makefile := `.venv:
+	python3 -m venv $@
+
+.md.html:
+	pandoc -o $@ $<
+
+.SUFFIXES: .md .html
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 3)
+
+	venv := ret.Rules[0]
+	assert.Equal(t, ExplicitRule, venv.Kind)
+	assert.Empty(t, venv.TargetPatterns)
+	_, ok := venv.MatchTarget("all")
+	assert.False(t, ok)
+
+	// suffixes declared later in the Makefile count as well
+	html := ret.Rules[1]
+	assert.Equal(t, SuffixRule, html.Kind)
+	assert.Equal(t, []string{"%.html"}, html.TargetPatterns)
+	assert.Equal(t, []string{"%.md"}, html.PrerequisitePatterns)
+
+	// a .SUFFIXES rule without prerequisites clears the default suffixes
+	ret, err = ParseReader("Makefile", strings.NewReader(".SUFFIXES:\n.c.o:\n\t$(CC) -c $<\n"))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 2)
+	assert.Equal(t, ExplicitRule, ret.Rules[1].Kind)
+	assert.True(t, ret.Settings.NoDefaultSuffixes)
+	assert.Empty(t, ret.Settings.KnownSuffixes())
+}
+
+func TestRuleKind_String(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "explicit", ExplicitRule.String())
+	assert.Equal(t, "pattern", PatternRule.String())
+	assert.Equal(t, "static-pattern", StaticPatternRule.String())
+	assert.Equal(t, "suffix", SuffixRule.String())
+}
+
+func TestRule_MatchTarget(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		rule   Rule
+		target string
+		stem   string
+		ok     bool
+	}{
+		{Rule{Targets: []string{"app"}}, "app", "", true},
+		{Rule{Targets: []string{"app"}}, "lib", "", false},
+		{Rule{Kind: PatternRule, TargetPatterns: []string{"%.o"}}, "src/main.o", "src/main", true},
+		{Rule{Kind: PatternRule, TargetPatterns: []string{"lib%.a"}}, "libfoo.a", "foo", true},
+		{Rule{Kind: PatternRule, TargetPatterns: []string{"%.o"}}, "main.c", "", false},
+		{Rule{Kind: StaticPatternRule, Targets: []string{"foo.o"}, TargetPatterns: []string{"%.o"}}, "foo.o", "foo", true},
+		{Rule{Kind: StaticPatternRule, Targets: []string{"foo.o"}, TargetPatterns: []string{"%.o"}}, "bar.o", "", false},
+		{Rule{Kind: SuffixRule, TargetPatterns: []string{"%.o"}}, "main.o", "main", true},
+	}
+
+	for _, test := range tests {
+		stem, ok := test.rule.MatchTarget(test.target)
+		assert.Equal(t, test.ok, ok, test.target)
+		assert.Equal(t, test.stem, stem, test.target)
+	}
+}
@@ -0,0 +1,35 @@
+package parser
+
+import "fmt"
+
+// Position is a location in a Makefile. Lines and columns are 1-based,
+// columns count bytes from the start of the physical line.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// String returns the position in the usual line:column notation
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// IsValid reports whether the position points to an actual location
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Range describes the part of a file a parsed node spans. Start points to
+// the first character of the node and End right after its last character,
+// which is the convention editors and SARIF use.
+type Range struct {
+	FileName string
+	Start    Position
+	End      Position
+}
+
+// String returns the range in file:line:column notation pointing to its
+// start
+func (r Range) String() string {
+	return fmt.Sprintf("%s:%s", r.FileName, r.Start)
+}
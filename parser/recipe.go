@@ -0,0 +1,56 @@
+package parser
+
+// RecipeLine represents a single logical line of a rule's recipe
+type RecipeLine struct {
+	// Text is the line as it is listed in Rule.Body, including prefixes
+	Text string
+	// Command is the command passed to the shell, without the prefixes
+	Command string
+	// Expression holds the variable references and function calls parsed
+	// from the command, which make expands before running it
+	Expression Expression
+	// Silent is set by the "@" prefix, which suppresses echoing the command
+	Silent bool
+	// IgnoreErrors is set by the "-" prefix, which makes make carry on if
+	// the command fails
+	IgnoreErrors bool
+	// AlwaysRun is set by the "+" prefix, which runs the command even with
+	// make -n, -t or -q
+	AlwaysRun  bool
+	FileName   string
+	LineNumber int
+	Range      Range
+}
+
+// RecipeLineList represents a list of recipe lines
+type RecipeLineList []RecipeLine
+
+// parseRecipeLine splits the prefixes off a recipe line spanning the given
+// range
+func parseRecipeLine(text string, rng Range) RecipeLine {
+	ret := RecipeLine{
+		Text:       text,
+		FileName:   rng.FileName,
+		LineNumber: rng.Start.Line,
+		Range:      rng,
+	}
+
+	command := text
+	for command != "" {
+		switch command[0] {
+		case '@':
+			ret.Silent = true
+		case '-':
+			ret.IgnoreErrors = true
+		case '+':
+			ret.AlwaysRun = true
+		case ' ', '\t':
+		default:
+			ret.Command = command
+			ret.Expression = ParseExpression(command)
+			return ret
+		}
+		command = command[1:]
+	}
+	return ret
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_RecipeLines(t *testing.T) {
+	t.Parallel()
+	makefile := `clean:
+	@-rm -f foo
+	+$(MAKE) -C sub clean
+	- @ echo done
+	echo plain
+quick: ; @echo quick
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 2)
+
+	clean := ret.Rules[0]
+	assert.Equal(t, []string{"@-rm -f foo", "+$(MAKE) -C sub clean", "- @ echo done", "echo plain"}, clean.Body)
+	require.Len(t, clean.Recipe, 4)
+
+	assert.Equal(t, RecipeLine{
+		Text:         "@-rm -f foo",
+		Command:      "rm -f foo",
+		Expression:   Expression{{Kind: TextNode, Text: "rm -f foo"}},
+		Silent:       true,
+		IgnoreErrors: true,
+		FileName:     "Makefile",
+		LineNumber:   2,
+		Range:        Range{FileName: "Makefile", Start: Position{2, 2}, End: Position{2, 13}},
+	}, clean.Recipe[0])
+
+	assert.Equal(t, "$(MAKE) -C sub clean", clean.Recipe[1].Command)
+	assert.True(t, clean.Recipe[1].AlwaysRun)
+	assert.False(t, clean.Recipe[1].Silent)
+
+	assert.Equal(t, "echo done", clean.Recipe[2].Command)
+	assert.True(t, clean.Recipe[2].Silent)
+	assert.True(t, clean.Recipe[2].IgnoreErrors)
+
+	assert.Equal(t, "echo plain", clean.Recipe[3].Command)
+	assert.False(t, clean.Recipe[3].Silent || clean.Recipe[3].IgnoreErrors || clean.Recipe[3].AlwaysRun)
+	assert.Equal(t, 5, clean.Recipe[3].LineNumber)
+
+	quick := ret.Rules[1]
+	require.Len(t, quick.Recipe, 1)
+	assert.Equal(t, "echo quick", quick.Recipe[0].Command)
+	assert.True(t, quick.Recipe[0].Silent)
+	assert.Equal(t, 6, quick.Recipe[0].LineNumber)
+}
+
+func TestParseRecipeLine_OnlyPrefixes(t *testing.T) {
+	t.Parallel()
+	line := parseRecipeLine("@-", Range{})
+
+	assert.Equal(t, "", line.Command)
+	assert.True(t, line.Silent)
+	assert.True(t, line.IgnoreErrors)
+}
@@ -1,45 +1,200 @@
 // Package parser implements all the parser functionality for Makefiles
-// this specific file holds the functionality for the scanner. The
-// implementation is a thin wrapper around bufio.Scanner with some extra
-// niceties to make parsing Makefiles easier.
+// this specific file holds the functionality for the scanner. The parser
+// scans the logical lines of the concrete syntax tree built from a
+// Makefile, see newTreeScanner. Scanners created by NewMakefileScanner and
+// NewMakefileScannerFromReader read through a bufio.Scanner instead, which
+// is kept for compatibility with code using them directly.
 package parser
 
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
-// MakefileScanner is a wrapping struct around bufio.Scanner which provides
-// extra functionality like the current line number
+// MakefileScanner provides the logical lines of a Makefile along with
+// extra functionality like the current line number. Every call to Scan
+// advances to the next logical line, which means physical lines ending in a
+// backslash are joined with the lines following them.
 type MakefileScanner struct {
-	Scanner    *bufio.Scanner
+	// Scanner is the scanner the lines are read from, it is nil for
+	// scanners reading a concrete syntax tree
+	Scanner *bufio.Scanner
+	// LineNumber is the physical line number the current logical line
+	// starts on, which is the same as FirstLine
 	LineNumber int
+	// FirstLine and LastLine are the physical line numbers of the first and
+	// the last line making up the current logical line
+	FirstLine int
+	LastLine  int
+	// FileHandle is only set for scanners created from a file path
 	FileHandle *os.File
-	Finished   bool
+	// FileName is the name the scanned content is reported under
+	FileName string
+	Finished bool
+
+	lines      []string
+	lastLength int
+
+	// tree is set for scanners reading the logical lines of a concrete
+	// syntax tree instead of Scanner, node is the current one
+	tree *CST
+	next int
+	node *CSTNode
 }
 
-// Scan is a thin wrapper around the bufio.Scanner Scan() function
+// Scan advances the scanner to the next logical line
 func (s *MakefileScanner) Scan() bool {
-	s.LineNumber++
-	scanResult := s.Scanner.Scan()
-	if !scanResult && s.Scanner.Err() == nil {
+	if s.tree != nil {
+		return s.scanTree()
+	}
+	s.lines = s.lines[:0]
+	s.FirstLine = s.LastLine + 1
+
+	for s.Scanner.Scan() {
+		s.LastLine++
+		line := s.Scanner.Text()
+		s.lines = append(s.lines, line)
+		s.lastLength = len(line)
+		if !isContinued(line) {
+			break
+		}
+	}
+
+	s.LineNumber = s.FirstLine
+	if len(s.lines) == 0 {
+		if s.Scanner.Err() == nil {
+			s.Finished = true
+		}
+		return false
+	}
+	return true
+}
+
+// scanTree advances the scanner to the next node of the tree
+func (s *MakefileScanner) scanTree() bool {
+	s.lines = s.lines[:0]
+	s.node = nil
+	if s.next >= len(s.tree.Nodes) {
+		s.FirstLine = s.LastLine + 1
+		s.LineNumber = s.FirstLine
 		s.Finished = true
+		return false
+	}
+
+	s.node = s.tree.Nodes[s.next]
+	s.next++
+	s.lines = append(s.lines, s.node.lines()...)
+	s.FirstLine = s.node.Range.Start.Line
+	s.LastLine = s.node.Range.End.Line
+	s.LineNumber = s.FirstLine
+	s.lastLength = len(s.lines[len(s.lines)-1])
+	return true
+}
+
+// mark sets the kind of the node of the current logical line and the rule
+// or define node it belongs to, if the scanner reads a tree
+func (s *MakefileScanner) mark(kind CSTKind, owner *CSTNode) {
+	if s.node != nil {
+		s.node.Kind = kind
+		s.node.owner = owner
 	}
-	return scanResult
 }
 
 // Close closes all open handles the scanner has
 func (s *MakefileScanner) Close() {
-	s.FileHandle.Close()
+	if s.FileHandle != nil {
+		s.FileHandle.Close()
+	}
 }
 
-// Text is a thin wrapper around bufio.Scanner Text()
+// Text returns the current logical line with continuations joined the way
+// make does outside of recipes: the backslash, the newline and all
+// whitespace around them are replaced by a single space.
 func (s *MakefileScanner) Text() string {
-	return s.Scanner.Text()
+	if len(s.lines) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, line := range s.lines {
+		if i > 0 {
+			line = strings.TrimLeft(line, " \t")
+		}
+		if i < len(s.lines)-1 {
+			line = strings.TrimRight(strings.TrimSuffix(line, "\\"), " \t")
+			b.WriteString(line)
+			b.WriteString(" ")
+			continue
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// RecipeText returns the current logical line with continuations joined the
+// way make does within recipes: backslash/newline pairs are kept as they are
+// passed to the shell, only a leading tab on continuation lines is removed.
+func (s *MakefileScanner) RecipeText() string {
+	return s.recipeText("\t")
+}
+
+// recipeText works like RecipeText for recipes with the given prefix, which
+// is removed from continuation lines instead of a tab
+func (s *MakefileScanner) recipeText(prefix string) string {
+	if len(s.lines) == 0 {
+		return ""
+	}
+
+	lines := make([]string, len(s.lines))
+	for i, line := range s.lines {
+		if i > 0 {
+			line = strings.TrimPrefix(line, prefix)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RawText returns the physical lines of the current logical line as they
+// are in the file
+func (s *MakefileScanner) RawText() string {
+	return strings.Join(s.lines, "\n")
+}
+
+// Range returns the range of the current logical line. It starts at the
+// first non-whitespace character and ends right after the last character of
+// the last physical line.
+func (s *MakefileScanner) Range() Range {
+	if len(s.lines) == 0 {
+		return Range{FileName: s.FileName}
+	}
+	first := s.lines[0]
+	last := s.lines[len(s.lines)-1]
+	return Range{
+		FileName: s.FileName,
+		Start: Position{
+			Line:   s.FirstLine,
+			Column: len(first) - len(strings.TrimLeft(first, " \t")) + 1,
+		},
+		End: Position{
+			Line:   s.LastLine,
+			Column: len(last) + 1,
+		},
+	}
 }
 
-// NewMakefileScanner returns a MakefileScanner struct for parsing a Makefile
+// endOfFile returns the position right after the last character of the
+// file, which is only meaningful once the scanner is finished
+func (s *MakefileScanner) endOfFile() Position {
+	return Position{Line: s.LastLine, Column: s.lastLength + 1}
+}
+
+// NewMakefileScanner returns a MakefileScanner struct for reading the
+// logical lines of a Makefile. The parser itself doesn't use it, it scans
+// the concrete syntax tree of the file instead.
 func NewMakefileScanner(filepath string) (*MakefileScanner, error) {
 	ret := &MakefileScanner{}
 	var fileOpenErr error
@@ -47,9 +202,33 @@ func NewMakefileScanner(filepath string) (*MakefileScanner, error) {
 	if fileOpenErr != nil {
 		return ret, fmt.Errorf("Error opening the provided filepath '%s'", filepath)
 	}
+	ret.FileName = ret.FileHandle.Name()
 	ret.Scanner = bufio.NewScanner(ret.FileHandle)
 	ret.Scanner.Split(bufio.ScanLines)
-	ret.LineNumber = 1
 
 	return ret, nil
 }
+
+// NewMakefileScannerFromReader returns a MakefileScanner struct for reading
+// the logical lines of a Makefile from the given reader, reporting its
+// content under the given name. Closing the reader is up to the caller.
+func NewMakefileScannerFromReader(name string, r io.Reader) *MakefileScanner {
+	ret := &MakefileScanner{FileName: name}
+	ret.Scanner = bufio.NewScanner(r)
+	ret.Scanner.Split(bufio.ScanLines)
+
+	return ret
+}
+
+// newTreeScanner returns a MakefileScanner reading the logical lines of the
+// given concrete syntax tree
+func newTreeScanner(tree *CST) *MakefileScanner {
+	return &MakefileScanner{FileName: tree.FileName, tree: tree}
+}
+
+// isContinued reports whether a physical line is continued on the next one,
+// which is the case if it ends in an odd number of backslashes
+func isContinued(line string) bool {
+	backslashes := len(line) - len(strings.TrimRight(line, "\\"))
+	return backslashes%2 == 1
+}
@@ -2,6 +2,9 @@ package parser
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateMakefileScanner(t *testing.T) {
@@ -18,3 +21,44 @@ func TestCreateMakefileScannerFailing(t *testing.T) {
 		t.Errorf("Unable to fail creating MakefileScanner for 'fixtures/idontexist.make'")
 	}
 }
+
+func TestMakefileScannerJoinsContinuationLines(t *testing.T) {
+	scanner, err := NewMakefileScanner("../fixtures/continuations.make")
+	require.NoError(t, err)
+	defer scanner.Close()
+
+	type logicalLine struct {
+		first, last int
+		text        string
+	}
+	var got []logicalLine
+	for scanner.Scan() {
+		got = append(got, logicalLine{scanner.FirstLine, scanner.LastLine, scanner.Text()})
+	}
+
+	assert.Equal(t, []logicalLine{
+		{1, 1, "# continued lines"},
+		{2, 4, "SRCS = a.c b.c c.c"},
+		{5, 5, `ESCAPED := C:\\`},
+		{6, 6, ""},
+		{7, 8, "all: one two"},
+		{9, 11, "\t@for f in $(SRCS); do echo $$f; done"},
+		{12, 12, "\t@echo done"},
+	}, got)
+	assert.True(t, scanner.Finished)
+}
+
+func TestMakefileScannerRecipeText(t *testing.T) {
+	scanner, err := NewMakefileScanner("../fixtures/continuations.make")
+	require.NoError(t, err)
+	defer scanner.Close()
+
+	for scanner.Scan() {
+		if scanner.FirstLine == 9 {
+			break
+		}
+	}
+
+	assert.Equal(t, "\t@for f in $(SRCS); do \\\n\techo $$f; \\\ndone", scanner.RecipeText())
+	assert.Equal(t, "\t@for f in $(SRCS); do \\\n\t\techo $$f; \\\n\tdone", scanner.RawText())
+}
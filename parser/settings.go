@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/checkmake/checkmake/logger"
+)
+
+// Settings holds the file-level settings made via special targets and
+// variables, which change how make treats the whole Makefile
+type Settings struct {
+	// RecipePrefix is the character recipe lines start with as set via
+	// .RECIPEPREFIX, it is empty for the default tab. As .RECIPEPREFIX can be
+	// changed anywhere, this is the value at the end of the Makefile.
+	RecipePrefix string
+	// OneShell is set by .ONESHELL, which runs all lines of a recipe in a
+	// single shell
+	OneShell bool
+	// Posix is set by .POSIX, which makes make conform to POSIX
+	Posix bool
+	// NotParallel is set by .NOTPARALLEL, which disables parallel execution
+	NotParallel bool
+	// DeleteOnError is set by .DELETE_ON_ERROR, which deletes the target of
+	// a failed recipe
+	DeleteOnError bool
+	// SecondExpansion is set by .SECONDEXPANSION, which expands
+	// prerequisites a second time
+	SecondExpansion bool
+	// Suffixes are the suffixes added to the known ones via .SUFFIXES
+	Suffixes []string
+	// NoDefaultSuffixes is set by a .SUFFIXES rule without prerequisites,
+	// which clears the known suffixes including make's default ones
+	NoDefaultSuffixes bool
+}
+
+// DefaultSuffixes are the suffixes GNU make knows without any .SUFFIXES
+// rule
+var DefaultSuffixes = []string{
+	".out", ".a", ".ln", ".o", ".c", ".cc", ".C", ".cpp", ".p", ".f", ".F",
+	".m", ".r", ".y", ".l", ".ym", ".yl", ".s", ".S", ".mod", ".sym", ".def",
+	".h", ".info", ".dvi", ".tex", ".texinfo", ".texi", ".txinfo", ".w",
+	".ch", ".web", ".sh", ".elc", ".el",
+}
+
+// KnownSuffixes returns the suffixes make considers for suffix rules
+func (s Settings) KnownSuffixes() []string {
+	if s.NoDefaultSuffixes {
+		return s.Suffixes
+	}
+	return append(slices.Clone(DefaultSuffixes), s.Suffixes...)
+}
+
+// applySpecialTarget records the setting made by a special target rule
+// with the given prerequisites
+func (s *Settings) applySpecialTarget(target string, prerequisites []string) {
+	switch target {
+	case ".SUFFIXES":
+		if len(prerequisites) == 0 {
+			s.Suffixes = nil
+			s.NoDefaultSuffixes = true
+		}
+		s.Suffixes = append(s.Suffixes, prerequisites...)
+	case ".ONESHELL":
+		s.OneShell = true
+	case ".POSIX":
+		s.Posix = true
+	case ".NOTPARALLEL":
+		s.NotParallel = true
+	case ".DELETE_ON_ERROR":
+		s.DeleteOnError = true
+	case ".SECONDEXPANSION":
+		s.SecondExpansion = true
+	}
+}
+
+// applyVariable records the setting made by assigning a special variable
+func (s *Settings) applyVariable(variable Variable) {
+	if variable.Name != ".RECIPEPREFIX" {
+		return
+	}
+	// make uses the first character of the value, which appending doesn't
+	// change: the appended text follows the current prefix, or the tab if
+	// it is still the default one
+	if variable.Operator == "+=" {
+		return
+	}
+	// make uses the first character of the expanded value, so a value
+	// referencing other variables can't be tracked
+	if strings.Contains(variable.Assignment, "$") {
+		logger.Debug(fmt.Sprintf("Unable to track .RECIPEPREFIX %q without evaluating variables", variable.Assignment))
+		return
+	}
+	prefix := variable.Assignment
+	if prefix == "" || prefix[0] == '\t' {
+		s.RecipePrefix = ""
+		return
+	}
+	s.RecipePrefix = prefix[:1]
+}
+
+// isRecipeLine reports whether the line starts with the recipe prefix, an
+// empty prefix stands for a tab
+func isRecipeLine(line, prefix string) bool {
+	if prefix == "" {
+		prefix = "\t"
+	}
+	return strings.HasPrefix(line, prefix)
+}
+
+// recipeBody returns the command of the recipe line the scanner resides on
+func recipeBody(scanner *MakefileScanner, prefix string) string {
+	if prefix == "" {
+		prefix = "\t"
+	}
+	return strings.TrimSpace(strings.TrimPrefix(scanner.recipeText(prefix), prefix))
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_RecipePrefix(t *testing.T) {
+	t.Parallel()
+	makefile := `.RECIPEPREFIX = >
+all: build
+> @echo all
+>	@echo \
+>	  continued
+
+build:
+> go build ./...
+.RECIPEPREFIX :=
+test:
+	go test ./...
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	require.Len(t, ret.Rules, 3)
+	assert.Empty(t, ret.Diagnostics)
+
+	assert.Equal(t, []string{"@echo all", "@echo \\\n\t  continued"}, ret.Rules[0].Body)
+	assert.Equal(t, []string{"go build ./..."}, ret.Rules[1].Body)
+	assert.Equal(t, []string{"go test ./..."}, ret.Rules[2].Body)
+	assert.Equal(t, "", ret.Settings.RecipePrefix)
+
+	require.Len(t, ret.Variables, 2)
+	assert.Equal(t, ".RECIPEPREFIX", ret.Variables[0].Name)
+}
+
+func TestParse_RecipePrefixUsesFirstCharacter(t *testing.T) {
+	t.Parallel()
+	ret, err := ParseReader("Makefile", strings.NewReader(".RECIPEPREFIX = >>\nall:\n>echo all\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, ">", ret.Settings.RecipePrefix)
+	require.Len(t, ret.Rules, 1)
+	assert.Equal(t, []string{"echo all"}, ret.Rules[0].Body)
+}
+
+func TestParse_RecipePrefixAppend(t *testing.T) {
+	t.Parallel()
+	// appending to the default prefix keeps the tab
+	ret, err := ParseReader("Makefile", strings.NewReader(".RECIPEPREFIX += >\nall:\n\techo all\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "", ret.Settings.RecipePrefix)
+	assert.Empty(t, ret.Diagnostics)
+	require.Len(t, ret.Rules, 1)
+	assert.Equal(t, []string{"echo all"}, ret.Rules[0].Body)
+
+	ret, err = ParseReader("Makefile", strings.NewReader(".RECIPEPREFIX = >\n.RECIPEPREFIX += x\nall:\n>echo all\n"))
+	require.NoError(t, err)
+	assert.Equal(t, ">", ret.Settings.RecipePrefix)
+}
+
+func TestParse_SpecialTargetSettings(t *testing.T) {
+	t.Parallel()
+	makefile := `.ONESHELL:
+.POSIX:
+.DELETE_ON_ERROR:
+.DEFAULT_GOAL := all
+all:
+	cd build
+	make
+`
+	ret, err := ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+
+	assert.Equal(t, Settings{
+		OneShell:      true,
+		Posix:         true,
+		DeleteOnError: true,
+	}, ret.Settings)
+
+	// .DEFAULT_GOAL is assigned, not a rule
+	require.Len(t, ret.Variables, 1)
+	assert.Equal(t, ".DEFAULT_GOAL", ret.Variables[0].Name)
+	assert.Equal(t, "all", ret.Variables[0].Assignment)
+
+	ret, err = ParseReader("Makefile", strings.NewReader(".NOTPARALLEL:\n.SECONDEXPANSION:\n"))
+	require.NoError(t, err)
+	assert.Equal(t, Settings{NotParallel: true, SecondExpansion: true}, ret.Settings)
+}
@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"github.com/checkmake/checkmake/parser"
+)
+
+// TextEdit replaces the text within a range of a file. Edits with an empty
+// range, where Start equals End, insert the text at that position.
+type TextEdit struct {
+	Range   parser.Range
+	NewText string
+}
+
+// Fixer can be implemented by rules which are able to repair their
+// violations. Fixes are only possible for the Makefile itself, whose source
+// is available as its CST, not for the files it includes.
+type Fixer interface {
+	// Fix returns the edits repairing the given violations, which the rule
+	// reported for the Makefile. Violations it can't repair are skipped.
+	Fix(makefile parser.Makefile, config RuleConfig, violations RuleViolationList) []TextEdit
+}
+
+// inMakefile reports whether a node parsed from fileName is part of the
+// Makefile itself rather than one of the files it includes
+func inMakefile(makefile parser.Makefile, fileName string) bool {
+	return FileNameFor(makefile, fileName) == makefile.FileName
+}
+
+// PhonyEdit returns the edit declaring the target phony. The target is
+// appended to the first unconditional .PHONY declaration of the Makefile
+// or, if there is none, declared on a new line above the first rule for the
+// target. It returns false if the Makefile has neither.
+func PhonyEdit(makefile parser.Makefile, target string) (TextEdit, bool) {
+	for _, rule := range makefile.Rules {
+		if rule.Target != ".PHONY" || len(rule.Conditions) > 0 || !inMakefile(makefile, rule.FileName) {
+			continue
+		}
+		// the target goes in front of a trailing comment
+		if rule.TrailingComment != nil {
+			start := rule.TrailingComment.Range.Start
+			return TextEdit{
+				Range:   parser.Range{FileName: rule.Range.FileName, Start: start, End: start},
+				NewText: target + " ",
+			}, true
+		}
+		end := rule.Range.End
+		return TextEdit{
+			Range:   parser.Range{FileName: rule.Range.FileName, Start: end, End: end},
+			NewText: " " + target,
+		}, true
+	}
+
+	for _, rule := range makefile.Rules {
+		if len(rule.Conditions) > 0 || !inMakefile(makefile, rule.FileName) {
+			continue
+		}
+		for _, name := range rule.Targets {
+			if name != target {
+				continue
+			}
+			// the declaration goes above the comments documenting the rule
+			line := rule.Range.Start.Line
+			if len(rule.Doc) > 0 {
+				line = rule.Doc[0].Range.Start.Line
+			}
+			start := parser.Position{Line: line, Column: 1}
+			return TextEdit{
+				Range:   parser.Range{FileName: rule.Range.FileName, Start: start, End: start},
+				NewText: ".PHONY: " + target + "\n",
+			}, true
+		}
+	}
+	return TextEdit{}, false
+}
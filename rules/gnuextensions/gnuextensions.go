@@ -0,0 +1,143 @@
+// Package gnuextensions implements the ruleset for making sure a Makefile
+// written for POSIX make doesn't use extensions only GNU make understands.
+package gnuextensions
+
+import (
+	"fmt"
+
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+)
+
+func init() {
+	rules.RegisterRule(&GNUExtensions{})
+}
+
+// GNUExtensions is an empty struct on which to call the rule functions
+type GNUExtensions struct{}
+
+var vT = "GNU make extension not supported by POSIX make: %s."
+
+// Name returns the name of the rule
+func (r *GNUExtensions) Name() string {
+	return "gnuextensions"
+}
+
+// Description returns the description of the rule
+func (r *GNUExtensions) Description(cfg rules.RuleConfig) string {
+	return "GNU make extensions must not be used when the dialect is posix"
+}
+
+// Run executes the rule logic. It only reports anything if the Makefile was
+// parsed with the posix dialect.
+func (r *GNUExtensions) Run(makefile parser.Makefile, config rules.RuleConfig) rules.RuleViolationList {
+	ret := rules.RuleViolationList{}
+	if makefile.Dialect != parser.POSIX {
+		return ret
+	}
+
+	report := func(extension, fileName string, lineNumber int, rng parser.Range) {
+		ret = append(ret, rules.RuleViolation{
+			Rule:       r.Name(),
+			Violation:  fmt.Sprintf(vT, extension),
+			FileName:   rules.FileNameFor(makefile, fileName),
+			LineNumber: lineNumber,
+			Range:      rng,
+		})
+	}
+
+	var conditionals func(parser.ConditionalList)
+	conditionals = func(list parser.ConditionalList) {
+		for _, conditional := range list {
+			report(fmt.Sprintf("'%s' conditional", conditional.Branches[0].Directive),
+				conditional.FileName, conditional.LineNumber, conditional.Range)
+			for _, branch := range conditional.Branches {
+				conditionals(branch.Conditionals)
+			}
+		}
+	}
+	conditionals(makefile.Conditionals)
+
+	for _, include := range makefile.Includes {
+		if include.Directive == "sinclude" {
+			report("'sinclude' directive", include.FileName, include.LineNumber, include.Range)
+		}
+	}
+	for _, export := range makefile.Exports {
+		report(fmt.Sprintf("'%s' directive", export.Directive), export.FileName, export.LineNumber, export.Range)
+	}
+	for _, undefine := range makefile.Undefines {
+		report("'undefine' directive", undefine.FileName, undefine.LineNumber, undefine.Range)
+	}
+	for _, vpath := range makefile.VPaths {
+		if vpath.Directive == "vpath" {
+			report("'vpath' directive", vpath.FileName, vpath.LineNumber, vpath.Range)
+		}
+	}
+
+	variables := append(parser.VariableList{}, makefile.Variables...)
+	variables = append(variables, makefile.TargetVariables...)
+	for _, variable := range variables {
+		var extensions []string
+		if len(variable.Targets) > 0 {
+			extensions = append(extensions, fmt.Sprintf("target-specific variable %q", variable.Name))
+		}
+		if variable.Define {
+			extensions = append(extensions, fmt.Sprintf("'define' of %q", variable.Name))
+		}
+		if variable.Operator == ":=" {
+			extensions = append(extensions, fmt.Sprintf("':=' assignment of %q, use '::='", variable.Name))
+		}
+		if variable.Override {
+			extensions = append(extensions, fmt.Sprintf("'override' of %q", variable.Name))
+		}
+		if variable.Exported {
+			extensions = append(extensions, fmt.Sprintf("'export' of %q", variable.Name))
+		}
+		if variable.Private {
+			extensions = append(extensions, fmt.Sprintf("'private' %q", variable.Name))
+		}
+		extensions = append(extensions, functionCalls(variable.Expression)...)
+		for _, extension := range extensions {
+			report(extension, variable.FileName, variable.LineNumber, variable.Range)
+		}
+	}
+
+	for _, rule := range makefile.Rules {
+		var extensions []string
+		switch rule.Kind {
+		case parser.PatternRule:
+			extensions = append(extensions, fmt.Sprintf("pattern rule %q", rule.Target))
+		case parser.StaticPatternRule:
+			extensions = append(extensions, fmt.Sprintf("static pattern rule %q", rule.Target))
+		}
+		if rule.Grouped {
+			extensions = append(extensions, fmt.Sprintf("grouped targets %q", rule.Target))
+		}
+		if len(rule.OrderOnlyDependencies) > 0 {
+			extensions = append(extensions, fmt.Sprintf("order-only prerequisites of %q", rule.Target))
+		}
+		for _, extension := range extensions {
+			report(extension, rule.FileName, rule.LineNumber, rule.Range)
+		}
+		for _, line := range rule.Recipe {
+			for _, extension := range functionCalls(line.Expression) {
+				report(extension, line.FileName, line.LineNumber, line.Range)
+			}
+		}
+	}
+
+	return ret
+}
+
+// functionCalls describes the calls of GNU make functions in an expression
+func functionCalls(expr parser.Expression) []string {
+	var ret []string
+	expr.Walk(func(node parser.Node) bool {
+		if node.Kind == parser.FunctionNode {
+			ret = append(ret, fmt.Sprintf("'%s' function", node.Name))
+		}
+		return true
+	})
+	return ret
+}
@@ -0,0 +1,80 @@
+package gnuextensions
+
+import (
+	"testing"
+
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+var gnuMakefile = parser.Makefile{
+	FileName: "Makefile",
+	Conditionals: parser.ConditionalList{{
+		Branches: []parser.ConditionalBranch{{
+			Directive: "ifeq",
+			Conditionals: parser.ConditionalList{{
+				Branches:   []parser.ConditionalBranch{{Directive: "ifdef"}},
+				LineNumber: 2,
+			}},
+		}},
+		LineNumber: 1,
+	}},
+	Includes: parser.IncludeList{
+		{Directive: "include", LineNumber: 5},
+		{Directive: "sinclude", LineNumber: 6},
+	},
+	Undefines: parser.UndefineList{
+		{Names: []string{"DEBUG"}, LineNumber: 6},
+	},
+	Variables: parser.VariableList{
+		{Name: "CC", Operator: "=", Expression: parser.ParseExpression("cc"), LineNumber: 7},
+		{Name: "NOW", Operator: ":=", Expression: parser.ParseExpression("$(shell date)"), LineNumber: 8},
+	},
+	Rules: parser.RuleList{
+		{Target: "all", Dependencies: []string{"build"}, LineNumber: 9},
+		{Target: "%.o", Kind: parser.PatternRule, LineNumber: 10, Recipe: parser.RecipeLineList{
+			{Expression: parser.ParseExpression("$(CC) -c $<"), LineNumber: 11},
+			{Expression: parser.ParseExpression("echo $(notdir $@)"), LineNumber: 12},
+		}},
+	},
+}
+
+func TestGNUExtensionsInPosixDialect(t *testing.T) {
+	t.Parallel()
+	makefile := gnuMakefile
+	makefile.Dialect = parser.POSIX
+
+	rule := GNUExtensions{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	violations := []string{}
+	lines := []int{}
+	for _, violation := range ret {
+		violations = append(violations, violation.Violation)
+		lines = append(lines, violation.LineNumber)
+		assert.Equal(t, "gnuextensions", violation.Rule)
+		assert.Equal(t, "Makefile", violation.FileName)
+	}
+	assert.Equal(t, []string{
+		"GNU make extension not supported by POSIX make: 'ifeq' conditional.",
+		"GNU make extension not supported by POSIX make: 'ifdef' conditional.",
+		"GNU make extension not supported by POSIX make: 'sinclude' directive.",
+		"GNU make extension not supported by POSIX make: 'undefine' directive.",
+		`GNU make extension not supported by POSIX make: ':=' assignment of "NOW", use '::='.`,
+		"GNU make extension not supported by POSIX make: 'shell' function.",
+		`GNU make extension not supported by POSIX make: pattern rule "%.o".`,
+		"GNU make extension not supported by POSIX make: 'notdir' function.",
+	}, violations)
+	assert.Equal(t, []int{1, 2, 6, 6, 8, 8, 10, 12}, lines)
+}
+
+func TestGNUExtensionsInOtherDialects(t *testing.T) {
+	t.Parallel()
+	rule := GNUExtensions{}
+	for _, dialect := range []parser.Dialect{parser.GNU, parser.BSD} {
+		makefile := gnuMakefile
+		makefile.Dialect = dialect
+		assert.Empty(t, rule.Run(makefile, rules.RuleConfig{}), dialect.String())
+	}
+}
@@ -38,6 +38,11 @@ func (m *MaxBodyLength) Description(cfg rules.RuleConfig) string {
 	return fmt.Sprintf("Target bodies should be kept simple and short (no more than %d lines).", maxBodyLength)
 }
 
+// DefaultSeverity returns the severity of the violations of the rule
+func (m *MaxBodyLength) DefaultSeverity() rules.Severity {
+	return rules.SeverityWarning
+}
+
 // Run executes the rule logic
 func (m *MaxBodyLength) Run(makefile parser.Makefile, config rules.RuleConfig) rules.RuleViolationList {
 	ret := rules.RuleViolationList{}
@@ -53,8 +58,9 @@ func (m *MaxBodyLength) Run(makefile parser.Makefile, config rules.RuleConfig) r
 			ret = append(ret, rules.RuleViolation{
 				Rule:       "maxbodylength",
 				Violation:  fmt.Sprintf(vT, rule.Target, maxBodyLength, len(rule.Body)),
-				FileName:   makefile.FileName,
+				FileName:   rules.FileNameFor(makefile, rule.FileName),
 				LineNumber: rule.LineNumber,
+				Range:      rule.Range,
 			})
 		}
 	}
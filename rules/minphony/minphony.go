@@ -40,12 +40,49 @@ func (r *MinPhony) Description(cfg rules.RuleConfig) string {
 	return fmt.Sprintf("Minimum required phony targets must be present (%s).", strings.Join(r.required, ","))
 }
 
+// DefaultSeverity returns the severity of the violations of the rule
+func (r *MinPhony) DefaultSeverity() rules.Severity {
+	return rules.SeverityWarning
+}
+
 // Run executes the rule logic.
 // It ensures all required phony targets are both defined as rules
 // and declared as PHONY. Missing or undeclared targets trigger violations.
 func (r *MinPhony) Run(makefile parser.Makefile, config rules.RuleConfig) rules.RuleViolationList {
 	ret := rules.RuleViolationList{}
+	for _, f := range r.check(makefile, config) {
+		ret = append(ret, f.violation)
+	}
+	return ret
+}
 
+// Fix declares the required targets of the violations phony. Missing
+// targets can't be fixed.
+func (r *MinPhony) Fix(makefile parser.Makefile, config rules.RuleConfig, violations rules.RuleViolationList) []rules.TextEdit {
+	var ret []rules.TextEdit
+	for _, f := range r.check(makefile, config) {
+		if !f.undeclared || !violations.Contains(f.violation) {
+			continue
+		}
+		if edit, ok := rules.PhonyEdit(makefile, f.target); ok {
+			ret = append(ret, edit)
+		}
+	}
+	return ret
+}
+
+// finding is a required target which is missing or not declared phony
+type finding struct {
+	target string
+	// undeclared is set for targets which are defined but not declared
+	// phony
+	undeclared bool
+	violation  rules.RuleViolation
+}
+
+// check returns the required targets which are missing or not declared
+// phony
+func (r *MinPhony) check(makefile parser.Makefile, config rules.RuleConfig) (ret []finding) {
 	// Load configured required targets, if any
 	required := r.required
 	if confRequired, ok := config["required"]; ok {
@@ -59,14 +96,22 @@ func (r *MinPhony) Run(makefile parser.Makefile, config rules.RuleConfig) rules.
 		}
 	}
 
+	// Variables in targets and prerequisites are expanded, e.g. in
+	// ".PHONY: $(TARGETS)"
+	evaluator := parser.NewEvaluator(makefile, parser.EvalOptions{})
+
 	// Collect all declared phony targets
 	declaredPhony := map[string]bool{}
 	phonyLine := 0
+	phonyFile := ""
+	phonyRange := parser.Range{}
 
 	// .PHONY parsed as variable (old behavior)
 	for _, variable := range makefile.Variables {
 		if variable.Name == "PHONY" {
 			phonyLine = variable.LineNumber
+			phonyFile = variable.FileName
+			phonyRange = variable.Range
 			for _, phony := range strings.Fields(variable.Assignment) {
 				declaredPhony[phony] = true
 			}
@@ -77,21 +122,23 @@ func (r *MinPhony) Run(makefile parser.Makefile, config rules.RuleConfig) rules.
 	for _, rule := range makefile.Rules {
 		if rule.Target == ".PHONY" || rule.Target == "PHONY" {
 			phonyLine = rule.LineNumber
-			for _, phony := range rule.Dependencies {
+			phonyFile = rule.FileName
+			phonyRange = rule.Range
+			for _, phony := range evaluator.ExpandWords(rule.Dependencies) {
 				declaredPhony[phony] = true
 			}
 		}
 	}
 
-	// NOTE: historically, when .PHONY was parsed as a variable,
-	// the reported line number was adjusted with `-1`. Now that
-	// .PHONY is parsed as a rule, we use the accurate line number.
-	// This change causes a +1 shift in violation line reporting.
+	// NOTE: violations point to the line of the last .PHONY declaration,
+	// which the parser reports accurately for rules and variables alike.
 	//
 	// Fallback: ensure phonyLine is never undefined
 	if phonyLine == 0 {
 		if len(makefile.Rules) > 0 {
 			phonyLine = makefile.Rules[len(makefile.Rules)-1].LineNumber
+			phonyFile = makefile.Rules[len(makefile.Rules)-1].FileName
+			phonyRange = makefile.Rules[len(makefile.Rules)-1].Range
 		}
 		if phonyLine == 0 {
 			phonyLine = -1 // match historical behavior for missing PHONY line
@@ -101,32 +148,36 @@ func (r *MinPhony) Run(makefile parser.Makefile, config rules.RuleConfig) rules.
 	// Collect all defined targets in the Makefile
 	definedTargets := map[string]bool{}
 	for _, rule := range makefile.Rules {
-		definedTargets[rule.Target] = true
+		for _, target := range evaluator.ExpandWords(rule.TargetNames()) {
+			definedTargets[target] = true
+		}
 	}
 
 	// Check for required targets being both defined and declared PHONY
 	for _, req := range required {
 		// Check if the required target is defined at all
 		if !definedTargets[req] {
-			ret = append(ret, rules.RuleViolation{
+			ret = append(ret, finding{req, false, rules.RuleViolation{
 				Rule:       r.Name(),
 				Violation:  fmt.Sprintf("Required target %q is missing from the Makefile.", req),
-				FileName:   makefile.FileName,
+				FileName:   rules.FileNameFor(makefile, phonyFile),
 				LineNumber: phonyLine,
-			})
+				Range:      phonyRange,
+			}})
 			continue
 		}
 
 		// Check if it’s declared PHONY
 		if !declaredPhony[req] {
-			ret = append(ret, rules.RuleViolation{
+			ret = append(ret, finding{req, true, rules.RuleViolation{
 				Rule:       r.Name(),
 				Violation:  fmt.Sprintf("Required target %q must be declared PHONY.", req),
-				FileName:   makefile.FileName,
+				FileName:   rules.FileNameFor(makefile, phonyFile),
 				LineNumber: phonyLine,
-			})
+				Range:      phonyRange,
+			}})
 		}
 	}
 
-	return ret
+	return
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/checkmake/checkmake/parser"
 	"github.com/checkmake/checkmake/rules"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var mpRunTests = []struct {
@@ -149,3 +150,61 @@ func TestMinPhony_MissingPhonyDeclaration(t *testing.T) {
 	assert.Equal(t, "Required target \"clean\" must be declared PHONY.", ret[0].Violation)
 	assert.Equal(t, "Required target \"test\" must be declared PHONY.", ret[1].Violation)
 }
+
+func TestMinPhony_MultipleTargetsPerRule(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "multi-target.mk",
+		Rules: []parser.Rule{
+			{Target: "all test", Targets: []string{"all", "test"}},
+			{Target: "clean distclean mrproper", Targets: []string{"clean", "distclean", "mrproper"}},
+		},
+		Variables: []parser.Variable{
+			{Name: "PHONY", Assignment: "all test clean distclean mrproper"},
+		},
+	}
+
+	mp := &MinPhony{required: []string{"all", "clean", "test"}}
+	ret := mp.Run(makefile, rules.RuleConfig{})
+
+	assert.Empty(t, ret, "targets of multi-target rules should count as defined")
+}
+
+func TestMinPhony_PhonyTargetsFromVariables(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "phony-variable.mk",
+		Rules: []parser.Rule{
+			{Target: ".PHONY", Targets: []string{".PHONY"}, Dependencies: []string{"$(TARGETS)"}},
+			{Target: "all", Targets: []string{"all"}},
+			{Target: "$(CLEAN_TARGETS)", Targets: []string{"$(CLEAN_TARGETS)"}},
+		},
+		Variables: []parser.Variable{
+			{Name: "CLEAN_TARGETS", Operator: ":=", Expression: parser.ParseExpression("clean test")},
+			{Name: "TARGETS", Operator: "=", Expression: parser.ParseExpression("all")},
+			{Name: "TARGETS", Operator: "+=", Expression: parser.ParseExpression("$(CLEAN_TARGETS)")},
+		},
+	}
+
+	mp := &MinPhony{required: []string{"all", "clean", "test"}}
+	ret := mp.Run(makefile, rules.RuleConfig{})
+
+	assert.Empty(t, ret, "targets declared PHONY through variables should be expanded")
+}
+
+func TestFixDeclaresRequiredTargetsPhony(t *testing.T) {
+	t.Parallel()
+	makefile, err := parser.ParseReader("Makefile", strings.NewReader(".PHONY: all\nall:\n\techo all\ntest:\n\techo test\n"))
+	require.NoError(t, err)
+
+	mp := &MinPhony{required: []string{"all", "clean", "test"}}
+	violations := mp.Run(makefile, rules.RuleConfig{})
+	require.Len(t, violations, 2)
+
+	// the missing clean target can't be fixed
+	end := parser.Position{Line: 1, Column: 12}
+	assert.Equal(t, []rules.TextEdit{{
+		Range:   parser.Range{FileName: "Makefile", Start: end, End: end},
+		NewText: " test",
+	}}, mp.Fix(makefile, rules.RuleConfig{}, violations))
+}
@@ -0,0 +1,64 @@
+// Package missinginclude implements the ruleset for making sure all files
+// referenced by non-optional include directives exist and that includes
+// don't form cycles.
+package missinginclude
+
+import (
+	"fmt"
+
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+)
+
+func init() {
+	rules.RegisterRule(&MissingInclude{})
+}
+
+// MissingInclude is an empty struct on which to call the rule functions
+type MissingInclude struct{}
+
+var vT = "Included file %q does not exist."
+
+var vTCycle = "Included file %q is already being parsed (include cycle)."
+
+// Name returns the name of the rule
+func (r *MissingInclude) Name() string {
+	return "missinginclude"
+}
+
+// Description returns the description of the rule
+func (r *MissingInclude) Description(cfg rules.RuleConfig) string {
+	return "Files included via 'include' must exist (use '-include' for optional files)"
+}
+
+// Run executes the rule logic. Missing includes and include cycles are only
+// known when the Makefile was parsed with includes being followed.
+func (r *MissingInclude) Run(makefile parser.Makefile, config rules.RuleConfig) rules.RuleViolationList {
+	ret := rules.RuleViolationList{}
+
+	for _, include := range makefile.Includes {
+		for _, cycle := range include.Cycles {
+			ret = append(ret, rules.RuleViolation{
+				Rule:       r.Name(),
+				Violation:  fmt.Sprintf(vTCycle, cycle),
+				FileName:   rules.FileNameFor(makefile, include.FileName),
+				LineNumber: include.LineNumber,
+				Range:      include.Range,
+			})
+		}
+		if include.Optional {
+			continue
+		}
+		for _, missing := range include.Missing {
+			ret = append(ret, rules.RuleViolation{
+				Rule:       r.Name(),
+				Violation:  fmt.Sprintf(vT, missing),
+				FileName:   rules.FileNameFor(makefile, include.FileName),
+				LineNumber: include.LineNumber,
+				Range:      include.Range,
+			})
+		}
+	}
+
+	return ret
+}
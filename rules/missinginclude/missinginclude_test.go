@@ -0,0 +1,65 @@
+package missinginclude
+
+import (
+	"testing"
+
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingInclude(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "Makefile",
+		Includes: parser.IncludeList{
+			{Directive: "include", Paths: []string{"a.mk", "b.mk"}, Missing: []string{"b.mk"}, FileName: "mk/common.mk", LineNumber: 3},
+			{Directive: "-include", Paths: []string{"c.mk"}, Optional: true, Missing: []string{"c.mk"}, LineNumber: 4},
+		},
+	}
+
+	rule := MissingInclude{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, rules.RuleViolationList{{
+		Rule:       "missinginclude",
+		Violation:  "Included file \"b.mk\" does not exist.",
+		FileName:   "mk/common.mk",
+		LineNumber: 3,
+	}}, ret)
+}
+
+func TestIncludeCycle(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "Makefile",
+		Includes: parser.IncludeList{
+			{Directive: "-include", Paths: []string{"../Makefile"}, Optional: true, Cycles: []string{"Makefile"}, FileName: "mk/common.mk", LineNumber: 2},
+		},
+	}
+
+	rule := MissingInclude{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, rules.RuleViolationList{{
+		Rule:       "missinginclude",
+		Violation:  "Included file \"Makefile\" is already being parsed (include cycle).",
+		FileName:   "mk/common.mk",
+		LineNumber: 2,
+	}}, ret)
+}
+
+func TestNoMissingInclude(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "Makefile",
+		Includes: parser.IncludeList{
+			{Directive: "include", Paths: []string{"a.mk"}, Resolved: []string{"a.mk"}, LineNumber: 1},
+		},
+	}
+
+	rule := MissingInclude{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, 0, len(ret))
+}
@@ -27,11 +27,44 @@ func (r *Phonydeclared) Description(cfg rules.RuleConfig) string {
 	return "Every target without a body needs to be marked PHONY"
 }
 
+// DefaultSeverity returns the severity of the violations of the rule
+func (r *Phonydeclared) DefaultSeverity() rules.Severity {
+	return rules.SeverityWarning
+}
+
 // Run executes the rule logic
 func (r *Phonydeclared) Run(makefile parser.Makefile, config rules.RuleConfig) rules.RuleViolationList {
 	ret := rules.RuleViolationList{}
+	for _, u := range r.undeclared(makefile) {
+		ret = append(ret, u.violation)
+	}
+	return ret
+}
+
+// Fix declares the targets of the violations phony
+func (r *Phonydeclared) Fix(makefile parser.Makefile, config rules.RuleConfig, violations rules.RuleViolationList) []rules.TextEdit {
+	var ret []rules.TextEdit
+	for _, u := range r.undeclared(makefile) {
+		if !violations.Contains(u.violation) {
+			continue
+		}
+		if edit, ok := rules.PhonyEdit(makefile, u.target); ok {
+			ret = append(ret, edit)
+		}
+	}
+	return ret
+}
+
+// undeclaredTarget is a target which should be declared phony
+type undeclaredTarget struct {
+	target    string
+	violation rules.RuleViolation
+}
 
+// undeclared returns the targets without a body which aren't declared phony
+func (r *Phonydeclared) undeclared(makefile parser.Makefile) (ret []undeclaredTarget) {
 	ruleIndex := make(map[string]bool)
+	evaluator := parser.NewEvaluator(makefile, parser.EvalOptions{})
 
 	// Case 1: .PHONY parsed as variable (old parser behavior)
 	for _, variable := range makefile.Variables {
@@ -45,28 +78,35 @@ func (r *Phonydeclared) Run(makefile parser.Makefile, config rules.RuleConfig) r
 	// Case 2: .PHONY parsed as rule (new parser behavior)
 	for _, rule := range makefile.Rules {
 		if rule.Target == ".PHONY" || rule.Target == "PHONY" {
-			for _, phony := range rule.Dependencies {
+			for _, phony := range evaluator.ExpandWords(rule.Dependencies) {
 				ruleIndex[phony] = true
 			}
 		}
 	}
 	// Check that every non-dot-prefixed target without a body is PHONY
 	for _, rule := range makefile.Rules {
-		// Skip special or dot-prefixed targets like .PHONY or .DEFAULT_GOAL
-		if strings.HasPrefix(rule.Target, ".") {
+		// Pattern and suffix rules don't define concrete targets
+		if len(rule.Body) > 0 || rule.Kind == parser.PatternRule || rule.Kind == parser.SuffixRule {
 			continue
 		}
 
-		_, ok := ruleIndex[rule.Target]
-		if len(rule.Body) == 0 && !ok {
-			ret = append(ret, rules.RuleViolation{
-				Rule:       "phonydeclared",
-				Violation:  fmt.Sprintf("Target %q should be declared PHONY.", rule.Target),
-				FileName:   makefile.FileName,
-				LineNumber: rule.LineNumber,
-			})
+		for _, target := range evaluator.ExpandWords(rule.TargetNames()) {
+			// Skip special or dot-prefixed targets like .PHONY or .DEFAULT_GOAL
+			if strings.HasPrefix(target, ".") {
+				continue
+			}
+
+			if !ruleIndex[target] {
+				ret = append(ret, undeclaredTarget{target, rules.RuleViolation{
+					Rule:       "phonydeclared",
+					Violation:  fmt.Sprintf("Target %q should be declared PHONY.", target),
+					FileName:   rules.FileNameFor(makefile, rule.FileName),
+					LineNumber: rule.LineNumber,
+					Range:      rule.Range,
+				}})
+			}
 		}
 	}
 
-	return ret
+	return
 }
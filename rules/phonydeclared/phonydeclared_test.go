@@ -1,11 +1,13 @@
 package phonydeclared
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/checkmake/checkmake/parser"
 	"github.com/checkmake/checkmake/rules"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAllTargetsArePhony(t *testing.T) {
@@ -55,3 +57,99 @@ func TestMissingOnePhonyTarget(t *testing.T) {
 		assert.Equal(t, "phony-declared-missing-one-phony.mk", ret[i].FileName)
 	}
 }
+
+func TestMultipleTargetsAreCheckedIndividually(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "phony-declared-multi-target.mk",
+		Rules: []parser.Rule{
+			{Target: ".PHONY", Dependencies: []string{"clean", "mrproper"}},
+			{Target: "clean distclean mrproper", Targets: []string{"clean", "distclean", "mrproper"}, LineNumber: 3},
+		},
+	}
+
+	rule := Phonydeclared{}
+
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Len(t, ret, 1)
+	assert.Equal(t, `Target "distclean" should be declared PHONY.`, ret[0].Violation)
+	assert.Equal(t, 3, ret[0].LineNumber)
+}
+
+func TestPatternAndSuffixRulesAreSkipped(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "phony-declared-patterns.mk",
+		Rules: []parser.Rule{
+			{Target: "%.o", Kind: parser.PatternRule, Dependencies: []string{"%.c"}},
+			{Target: ".c.o", Kind: parser.SuffixRule},
+			{Target: "foo.o bar.o", Kind: parser.StaticPatternRule, Dependencies: []string{"%.c"}},
+		},
+	}
+
+	rule := Phonydeclared{}
+
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Len(t, ret, 2, "only the concrete targets of the static pattern rule should be reported")
+	assert.Equal(t, `Target "foo.o" should be declared PHONY.`, ret[0].Violation)
+	assert.Equal(t, `Target "bar.o" should be declared PHONY.`, ret[1].Violation)
+}
+
+func TestPhonyTargetsFromVariables(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "phony-declared-variables.mk",
+		Variables: parser.VariableList{
+			{Name: "TARGETS", Operator: "=", Expression: parser.ParseExpression("all clean")},
+			{Name: "TOOLS", Operator: ":=", Expression: parser.ParseExpression("lint vet")},
+		},
+		Rules: []parser.Rule{
+			{Target: ".PHONY", Dependencies: []string{"$(TARGETS)", "lint"}},
+			{Target: "$(TARGETS)", Targets: []string{"$(TARGETS)"}},
+			{Target: "$(TOOLS)", Targets: []string{"$(TOOLS)"}, LineNumber: 4},
+		},
+	}
+
+	rule := Phonydeclared{}
+
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Len(t, ret, 1)
+	assert.Equal(t, `Target "vet" should be declared PHONY.`, ret[0].Violation)
+	assert.Equal(t, 4, ret[0].LineNumber)
+}
+
+func TestFixAddsTargetsToPhony(t *testing.T) {
+	t.Parallel()
+	makefile, err := parser.ParseReader("Makefile", strings.NewReader(".PHONY: all # main\nall:\nclean:\n"))
+	require.NoError(t, err)
+
+	rule := Phonydeclared{}
+	edits := rule.Fix(makefile, rules.RuleConfig{}, rule.Run(makefile, rules.RuleConfig{}))
+
+	start := parser.Position{Line: 1, Column: 13}
+	assert.Equal(t, []rules.TextEdit{{
+		Range:   parser.Range{FileName: "Makefile", Start: start, End: start},
+		NewText: "clean ",
+	}}, edits)
+}
+
+func TestFixDeclaresPhonyAboveRule(t *testing.T) {
+	t.Parallel()
+	makefile, err := parser.ParseReader("Makefile", strings.NewReader("all: build\n\n# remove build artifacts\nclean:\n"))
+	require.NoError(t, err)
+
+	rule := Phonydeclared{}
+	violations := rule.Run(makefile, rules.RuleConfig{})
+	require.Len(t, violations, 2)
+
+	// only the violations passed in are fixed
+	edits := rule.Fix(makefile, rules.RuleConfig{}, violations[1:])
+	start := parser.Position{Line: 3, Column: 1}
+	assert.Equal(t, []rules.TextEdit{{
+		Range:   parser.Range{FileName: "Makefile", Start: start, End: start},
+		NewText: ".PHONY: clean\n",
+	}}, edits)
+}
@@ -16,6 +16,9 @@ import (
 //     but must remain safe to call with a nil config (using default values).
 //   - Run(makefile, cfg): performs the actual validation on the parsed Makefile,
 //     returning a list of any violations found.
+//
+// Rules whose violations aren't errors by default implement SeverityRule as
+// well.
 type Rule interface {
 	Name() string
 	Description(cfg RuleConfig) string
@@ -28,12 +31,56 @@ type RuleViolation struct {
 	Violation  string
 	FileName   string
 	LineNumber int
+	// Severity is filled in by the validator, rules leave it empty
+	Severity Severity
+	// Range points to the offending text, it is empty for violations that
+	// can't be tied to a specific part of the Makefile
+	Range parser.Range
+}
+
+// Column returns the column the violation starts at or 0 if unknown
+func (v RuleViolation) Column() int {
+	return v.Range.Start.Column
+}
+
+// EndLineNumber returns the line the violation ends on or 0 if unknown
+func (v RuleViolation) EndLineNumber() int {
+	return v.Range.End.Line
+}
+
+// EndColumn returns the column right after the end of the violation or 0
+// if unknown
+func (v RuleViolation) EndColumn() int {
+	return v.Range.End.Column
 }
 
 // RuleViolationList is a list of Violation types and the return type of a
 // Rule function
 type RuleViolationList []RuleViolation
 
+// Contains reports whether the list holds a violation of the same rule with
+// the same message at the same position, regardless of its severity
+func (l RuleViolationList) Contains(violation RuleViolation) bool {
+	for _, v := range l {
+		v.Severity = violation.Severity
+		if v == violation {
+			return true
+		}
+	}
+	return false
+}
+
+// FileNameFor returns the file name a violation for a node parsed from
+// fileName should be attributed to. Rules and variables pulled in from
+// included files carry their own file name, nodes without one (e.g. when
+// constructed by hand) are attributed to the Makefile itself.
+func FileNameFor(makefile parser.Makefile, fileName string) string {
+	if fileName != "" {
+		return fileName
+	}
+	return makefile.FileName
+}
+
 // RuleConfig is a simple string/string map to hold key/value configuration
 // for rules.
 type RuleConfig map[string]string
@@ -0,0 +1,65 @@
+package rules
+
+import "fmt"
+
+// Severity is the severity of a rule violation
+type Severity string
+
+const (
+	// SeverityError is for violations which break the Makefile or are very
+	// likely bugs
+	SeverityError Severity = "error"
+	// SeverityWarning is for violations of good practice
+	SeverityWarning Severity = "warning"
+	// SeverityInfo is for violations which are merely worth knowing about
+	SeverityInfo Severity = "info"
+)
+
+// ParseSeverity returns the severity with the given name
+func ParseSeverity(name string) (Severity, error) {
+	switch severity := Severity(name); severity {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return severity, nil
+	}
+	return "", fmt.Errorf("invalid severity %q (supported: error, warning, info)", name)
+}
+
+// String returns the name of the severity. An empty severity is an error.
+func (s Severity) String() string {
+	if s == "" {
+		return string(SeverityError)
+	}
+	return string(s)
+}
+
+// AtLeast reports whether the severity is the same as or more severe than
+// the other one. An empty severity counts as an error.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+// rank orders the severities from info to error
+func (s Severity) rank() int {
+	switch s {
+	case SeverityInfo:
+		return 0
+	case SeverityWarning:
+		return 1
+	}
+	return 2
+}
+
+// SeverityRule can be implemented by rules whose violations are not errors
+// by default. Rules which don't implement it report errors.
+type SeverityRule interface {
+	DefaultSeverity() Severity
+}
+
+// DefaultSeverity returns the severity of the violations of the rule when
+// none is configured
+func DefaultSeverity(rule Rule) Severity {
+	if r, ok := rule.(SeverityRule); ok {
+		return r.DefaultSeverity()
+	}
+	return SeverityError
+}
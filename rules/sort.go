@@ -0,0 +1,51 @@
+package rules
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// SortOrder selects the order violations are reported in
+type SortOrder string
+
+const (
+	// SortByFile orders violations by file, line, column and rule
+	SortByFile SortOrder = "file"
+	// SortByRule orders violations by rule, then like SortByFile
+	SortByRule SortOrder = "rule"
+	// SortBySeverity orders violations from errors to infos, then like
+	// SortByFile
+	SortBySeverity SortOrder = "severity"
+)
+
+// ParseSortOrder returns the sort order with the given name
+func ParseSortOrder(name string) (SortOrder, error) {
+	switch order := SortOrder(name); order {
+	case SortByFile, SortByRule, SortBySeverity:
+		return order, nil
+	}
+	return "", fmt.Errorf("invalid sort order %q (supported: file, rule, severity)", name)
+}
+
+// Sort sorts the violations in the given order. Violations which are equal
+// in every respect keep their order.
+func (l RuleViolationList) Sort(order SortOrder) {
+	slices.SortStableFunc(l, func(a, b RuleViolation) int {
+		byPosition := cmp.Or(
+			cmp.Compare(a.FileName, b.FileName),
+			cmp.Compare(a.LineNumber, b.LineNumber),
+			cmp.Compare(a.Column(), b.Column()),
+		)
+		var ret int
+		switch order {
+		case SortByRule:
+			ret = cmp.Or(cmp.Compare(a.Rule, b.Rule), byPosition)
+		case SortBySeverity:
+			ret = cmp.Or(cmp.Compare(b.Severity.rank(), a.Severity.rank()), byPosition, cmp.Compare(a.Rule, b.Rule))
+		default:
+			ret = cmp.Or(byPosition, cmp.Compare(a.Rule, b.Rule))
+		}
+		return cmp.Or(ret, cmp.Compare(a.Violation, b.Violation))
+	})
+}
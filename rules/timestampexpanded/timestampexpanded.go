@@ -8,6 +8,7 @@ package timestampexpanded
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/checkmake/checkmake/parser"
@@ -33,21 +34,80 @@ func (r *Timestampexpanded) Description(cfg rules.RuleConfig) string {
 	return "timestamp variables should be simply expanded"
 }
 
+// DefaultSeverity returns the severity of the violations of the rule
+func (r *Timestampexpanded) DefaultSeverity() rules.Severity {
+	return rules.SeverityWarning
+}
+
 // Run executes the rule logic
 func (r *Timestampexpanded) Run(makefile parser.Makefile, config rules.RuleConfig) rules.RuleViolationList {
 	ret := rules.RuleViolationList{}
 
 	for _, variable := range makefile.Variables {
-		if strings.Contains(variable.Assignment, " date") &&
-			!variable.SimplyExpanded {
-			ret = append(ret, rules.RuleViolation{
-				Rule:       "timestampexpanded",
-				Violation:  fmt.Sprintf(vT, variable.Name),
-				FileName:   makefile.FileName,
-				LineNumber: variable.LineNumber,
-			})
+		if !variable.SimplyExpanded && runsDate(variable.Expression) {
+			ret = append(ret, violation(makefile, variable))
 		}
 	}
 
 	return ret
 }
+
+// Fix turns the "=" assignments of the violations into ":=" ones, or "::="
+// ones for POSIX make. Other operators like "+=" are left alone.
+func (r *Timestampexpanded) Fix(makefile parser.Makefile, config rules.RuleConfig, violations rules.RuleViolationList) []rules.TextEdit {
+	if makefile.CST == nil {
+		return nil
+	}
+	colons := ":"
+	if makefile.Dialect == parser.POSIX {
+		colons = "::"
+	}
+
+	var ret []rules.TextEdit
+	for _, variable := range makefile.Variables {
+		if variable.Operator != "=" || variable.Define || len(variable.Targets) > 0 ||
+			rules.FileNameFor(makefile, variable.FileName) != makefile.FileName ||
+			!violations.Contains(violation(makefile, variable)) {
+			continue
+		}
+		// the operator is the first "=" of the line, names can't contain one
+		node := makefile.CST.NodeAt(variable.Range.Start.Line)
+		if node == nil || node.Kind != parser.CSTVariable {
+			continue
+		}
+		line, _, _ := strings.Cut(node.Text, "\n")
+		idx := strings.Index(line, "=")
+		if idx == -1 {
+			continue
+		}
+		pos := parser.Position{Line: variable.Range.Start.Line, Column: idx + 1}
+		ret = append(ret, rules.TextEdit{
+			Range:   parser.Range{FileName: variable.Range.FileName, Start: pos, End: pos},
+			NewText: colons,
+		})
+	}
+	return ret
+}
+
+// violation returns the violation for the variable
+func violation(makefile parser.Makefile, variable parser.Variable) rules.RuleViolation {
+	return rules.RuleViolation{
+		Rule:       "timestampexpanded",
+		Violation:  fmt.Sprintf(vT, variable.Name),
+		FileName:   rules.FileNameFor(makefile, variable.FileName),
+		LineNumber: variable.LineNumber,
+		Range:      variable.Range,
+	}
+}
+
+// runsDate reports whether the expression calls the shell to run date(1)
+func runsDate(expr parser.Expression) bool {
+	for _, call := range expr.Calls("shell") {
+		for _, word := range strings.Fields(call.Args[0].String()) {
+			if path.Base(word) == "date" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,11 +1,13 @@
 package timestampexpanded
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/checkmake/checkmake/parser"
 	"github.com/checkmake/checkmake/rules"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVersionIsNotSimplyExpanded(t *testing.T) {
@@ -14,6 +16,7 @@ func TestVersionIsNotSimplyExpanded(t *testing.T) {
 		Variables: []parser.Variable{{
 			Name:           "BUILDTIME",
 			Assignment:     "$(shell date -u +\"%Y-%m-%dT%H:%M:%SZ\")",
+			Expression:     parser.ParseExpression("$(shell date -u +\"%Y-%m-%dT%H:%M:%SZ\")"),
 			SimplyExpanded: false,
 		}},
 	}
@@ -36,6 +39,7 @@ func TestVersionIsSimplyExpanded(t *testing.T) {
 		Variables: []parser.Variable{{
 			Name:           "BUILDTIME",
 			Assignment:     "$(shell date -u +\"%Y-%m-%dT%H:%M:%SZ\")",
+			Expression:     parser.ParseExpression("$(shell date -u +\"%Y-%m-%dT%H:%M:%SZ\")"),
 			SimplyExpanded: true,
 		}},
 	}
@@ -46,3 +50,47 @@ func TestVersionIsSimplyExpanded(t *testing.T) {
 
 	assert.Equal(t, 0, len(ret))
 }
+
+func TestDateOutsideOfShell(t *testing.T) {
+	makefile := parser.Makefile{
+		FileName: "timestamp-text.mk",
+		Variables: []parser.Variable{{
+			Name:       "HELP",
+			Assignment: "prints the build date",
+			Expression: parser.ParseExpression("prints the build date"),
+		}, {
+			Name:       "STAMP",
+			Assignment: "$(shell TZ=UTC /bin/date +%s)",
+			Expression: parser.ParseExpression("$(shell TZ=UTC /bin/date +%s)"),
+		}},
+	}
+
+	rule := Timestampexpanded{}
+
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, 1, len(ret))
+	assert.Equal(t, `Variable "STAMP" possibly contains a timestamp and should be simply expanded.`, ret[0].Violation)
+}
+
+func TestFixSimplyExpandsTimestamps(t *testing.T) {
+	makefile, err := parser.ParseReader("Makefile", strings.NewReader("ifdef X\n  override NOW=$(shell date)\nendif\nLATER += $(shell date)\n"))
+	require.NoError(t, err)
+
+	rule := Timestampexpanded{}
+	violations := rule.Run(makefile, rules.RuleConfig{})
+	require.Len(t, violations, 2)
+
+	// appends are left alone
+	pos := parser.Position{Line: 2, Column: 15}
+	assert.Equal(t, []rules.TextEdit{{
+		Range:   parser.Range{FileName: "Makefile", Start: pos, End: pos},
+		NewText: ":",
+	}}, rule.Fix(makefile, rules.RuleConfig{}, violations))
+
+	makefile, err = parser.ParseReaderWithOptions("Makefile", strings.NewReader("NOW = $(shell date)\n"), parser.ParseOptions{Dialect: parser.POSIX})
+	require.NoError(t, err)
+	edits := rule.Fix(makefile, rules.RuleConfig{}, rule.Run(makefile, rules.RuleConfig{}))
+	require.Len(t, edits, 1)
+	assert.Equal(t, "::", edits[0].NewText)
+}
@@ -3,6 +3,7 @@ package uniquetargets
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/checkmake/checkmake/parser"
@@ -33,7 +34,7 @@ func (r *UniqueTargets) Description(cfg rules.RuleConfig) string {
 
 // Run detects non-unique target definitions, optionally skipping ignored ones.
 func (r *UniqueTargets) Run(makefile parser.Makefile, cfg rules.RuleConfig) rules.RuleViolationList {
-	seen := make(map[string]int)
+	seen := make(map[string][]parser.Rule)
 	violations := rules.RuleViolationList{}
 
 	// Load optional ignore list
@@ -50,25 +51,45 @@ func (r *UniqueTargets) Run(makefile parser.Makefile, cfg rules.RuleConfig) rule
 	}
 
 	for _, rule := range makefile.Rules {
-		// Skip ignored targets
-		if ignoredTargets[rule.Target] {
-			continue
-		}
+		for _, target := range rule.TargetNames() {
+			// Skip ignored targets
+			if ignoredTargets[target] {
+				continue
+			}
 
-		// Skip special built-ins like .PHONY
-		if rule.Target == ".PHONY" {
-			continue
-		}
+			// Skip special built-ins like .PHONY
+			if target == ".PHONY" {
+				continue
+			}
 
-		if prevLine, exists := seen[rule.Target]; exists {
-			violations = append(violations, rules.RuleViolation{
-				Rule:       r.Name(),
-				Violation:  fmt.Sprintf(`Target "%s" defined multiple times (lines %d and %d).`, rule.Target, prevLine, rule.LineNumber),
-				FileName:   makefile.FileName,
-				LineNumber: rule.LineNumber,
-			})
-		} else {
-			seen[rule.Target] = rule.LineNumber
+			// Definitions in different branches of the same conditional never
+			// apply at the same time, e.g. ifeq ($(OS),Windows_NT) ... else ... endif
+			duplicate := false
+			for _, prev := range seen[target] {
+				// double-colon rules are meant to be defined several times
+				if prev.DoubleColon && rule.DoubleColon {
+					continue
+				}
+				// a pattern rule only replaces one with the same prerequisites,
+				// e.g. "%.o: %.c" and "%.o: %.cpp" can coexist
+				if rule.Kind == parser.PatternRule && !slices.Equal(prev.Dependencies, rule.Dependencies) {
+					continue
+				}
+				if !parser.MutuallyExclusive(prev.Conditions, rule.Conditions) {
+					violations = append(violations, rules.RuleViolation{
+						Rule:       r.Name(),
+						Violation:  fmt.Sprintf(`Target "%s" defined multiple times (lines %d and %d).`, target, prev.LineNumber, rule.LineNumber),
+						FileName:   rules.FileNameFor(makefile, rule.FileName),
+						LineNumber: rule.LineNumber,
+						Range:      rule.Range,
+					})
+					duplicate = true
+					break
+				}
+			}
+			if !duplicate {
+				seen[target] = append(seen[target], rule)
+			}
 		}
 	}
 
@@ -86,3 +86,77 @@ func TestPhonyTargetsAreIgnored(t *testing.T) {
 	assert.Equal(t, 1, len(ret), "only non-.PHONY duplicates should trigger violations")
 	assert.Contains(t, ret[0].Violation, `"build" defined multiple times`)
 }
+
+func TestTargetsInExclusiveConditionalBranches(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "conditional_targets.mk",
+		Rules: []parser.Rule{
+			{Target: "build", LineNumber: 2, Conditions: []parser.ConditionalBranchRef{{Conditional: 0, Branch: 0}}},
+			{Target: "build", LineNumber: 5, Conditions: []parser.ConditionalBranchRef{{Conditional: 0, Branch: 1}}},
+			{Target: "build", LineNumber: 8}, // unconditional, clashes with both
+		},
+	}
+
+	rule := UniqueTargets{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, 1, len(ret), "only the unconditional duplicate should trigger a violation")
+	assert.Contains(t, ret[0].Violation, `(lines 2 and 8)`)
+	assert.Equal(t, 8, ret[0].LineNumber)
+}
+
+func TestDoubleColonRulesAreAllowed(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "double_colon.mk",
+		Rules: []parser.Rule{
+			{Target: "install", LineNumber: 1, DoubleColon: true},
+			{Target: "install", LineNumber: 4, DoubleColon: true},
+			{Target: "install", LineNumber: 7}, // mixing single and double colon
+		},
+	}
+
+	rule := UniqueTargets{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, 1, len(ret), "only the single-colon rule should trigger a violation")
+	assert.Contains(t, ret[0].Violation, `(lines 1 and 7)`)
+	assert.Equal(t, 7, ret[0].LineNumber)
+}
+
+func TestMultipleTargetsAreCheckedIndividually(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "multi_target.mk",
+		Rules: []parser.Rule{
+			{Target: "clean distclean", Targets: []string{"clean", "distclean"}, LineNumber: 1},
+			{Target: "a.h b.h", Targets: []string{"a.h", "b.h"}, Grouped: true, LineNumber: 4},
+			{Target: "distclean", Targets: []string{"distclean"}, LineNumber: 7},
+		},
+	}
+
+	rule := UniqueTargets{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, 1, len(ret), "expected one duplicate violation")
+	assert.Contains(t, ret[0].Violation, `"distclean" defined multiple times (lines 1 and 7)`)
+}
+
+func TestPatternRulesWithDifferentPrerequisites(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "patterns.mk",
+		Rules: []parser.Rule{
+			{Target: "%.o", Kind: parser.PatternRule, Dependencies: []string{"%.c"}, LineNumber: 1},
+			{Target: "%.o", Kind: parser.PatternRule, Dependencies: []string{"%.cpp"}, LineNumber: 4},
+			{Target: "%.o", Kind: parser.PatternRule, Dependencies: []string{"%.c"}, LineNumber: 7}, // replaces the first one
+		},
+	}
+
+	rule := UniqueTargets{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, 1, len(ret), "only the pattern rule with identical prerequisites should trigger a violation")
+	assert.Contains(t, ret[0].Violation, `(lines 1 and 7)`)
+}
@@ -0,0 +1,46 @@
+// Package unrecognizedline implements the ruleset for making sure every line
+// of a Makefile could be parsed.
+package unrecognizedline
+
+import (
+	"fmt"
+
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+)
+
+func init() {
+	rules.RegisterRule(&UnrecognizedLine{})
+}
+
+// UnrecognizedLine is an empty struct on which to call the rule functions
+type UnrecognizedLine struct{}
+
+var vT = "Line %q could not be parsed: %s."
+
+// Name returns the name of the rule
+func (r *UnrecognizedLine) Name() string {
+	return "unrecognizedline"
+}
+
+// Description returns the description of the rule
+func (r *UnrecognizedLine) Description(cfg rules.RuleConfig) string {
+	return "Every line must be a rule, recipe, variable, directive or comment"
+}
+
+// Run executes the rule logic
+func (r *UnrecognizedLine) Run(makefile parser.Makefile, config rules.RuleConfig) rules.RuleViolationList {
+	ret := rules.RuleViolationList{}
+
+	for _, diagnostic := range makefile.Diagnostics {
+		ret = append(ret, rules.RuleViolation{
+			Rule:       r.Name(),
+			Violation:  fmt.Sprintf(vT, diagnostic.Text, diagnostic.Reason),
+			FileName:   rules.FileNameFor(makefile, diagnostic.FileName),
+			LineNumber: diagnostic.LineNumber,
+			Range:      diagnostic.Range,
+		})
+	}
+
+	return ret
+}
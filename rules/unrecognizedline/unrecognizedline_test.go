@@ -0,0 +1,70 @@
+package unrecognizedline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnrecognizedLine(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "Makefile",
+		Diagnostics: parser.DiagnosticList{
+			{Reason: "neither a rule nor a variable", Text: "thisisnotarule", LineNumber: 4},
+			{Reason: "recipe line indented with spaces", Text: "go build", FileName: "mk/build.mk", LineNumber: 7},
+		},
+	}
+
+	rule := UnrecognizedLine{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, rules.RuleViolationList{
+		{
+			Rule:       "unrecognizedline",
+			Violation:  `Line "thisisnotarule" could not be parsed: neither a rule nor a variable.`,
+			FileName:   "Makefile",
+			LineNumber: 4,
+		},
+		{
+			Rule:       "unrecognizedline",
+			Violation:  `Line "go build" could not be parsed: recipe line indented with spaces.`,
+			FileName:   "mk/build.mk",
+			LineNumber: 7,
+		},
+	}, ret)
+}
+
+func TestNoUnrecognizedLines(t *testing.T) {
+	t.Parallel()
+	makefile := parser.Makefile{
+		FileName: "Makefile",
+		Rules:    parser.RuleList{{Target: "all", LineNumber: 1}},
+	}
+
+	rule := UnrecognizedLine{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Equal(t, 0, len(ret))
+}
+
+func TestValidLinesAreRecognized(t *testing.T) {
+	t.Parallel()
+	makefile, err := parser.ParseReader("Makefile", strings.NewReader(`ifndef X
+$(error Please set X (see README))
+endif
+$(info hi (there))
+undefine FOO
+$(PREFIX)_CFLAGS = -O2
+`))
+	require.NoError(t, err)
+
+	rule := UnrecognizedLine{}
+	ret := rule.Run(makefile, rules.RuleConfig{})
+
+	assert.Empty(t, ret)
+}
@@ -0,0 +1,109 @@
+package validator
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/checkmake/checkmake/config"
+	"github.com/checkmake/checkmake/logger"
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+)
+
+// Fix returns the edits repairing the violations of the Makefile, as far as
+// their rules implement rules.Fixer. Violations of files included by the
+// Makefile are left alone. Rules are asked in alphabetical order, an edit
+// overlapping one of an earlier rule is dropped and identical edits are
+// only applied once. The edits are sorted by position.
+func Fix(makefile parser.Makefile, cfg *config.Config, violations rules.RuleViolationList) (ret []rules.TextEdit) {
+	for _, rule := range rules.GetRulesSorted() {
+		fixer, ok := rule.(rules.Fixer)
+		if !ok {
+			continue
+		}
+		var ruleViolations rules.RuleViolationList
+		for _, violation := range violations {
+			if violation.Rule == rule.Name() && violation.FileName == makefile.FileName {
+				ruleViolations = append(ruleViolations, violation)
+			}
+		}
+		if len(ruleViolations) == 0 {
+			continue
+		}
+
+		logger.Debug(fmt.Sprintf("Fixing violations of rule '%s'...", rule.Name()))
+		for _, edit := range fixer.Fix(makefile, cfg.GetRuleConfig(rule.Name()), ruleViolations) {
+			switch {
+			case slices.Contains(ret, edit):
+			case slices.ContainsFunc(ret, func(other rules.TextEdit) bool { return overlaps(edit, other) }):
+				logger.Info(fmt.Sprintf("Skipping fix of rule '%s' at line %d, it conflicts with another one", rule.Name(), edit.Range.Start.Line))
+			default:
+				ret = append(ret, edit)
+			}
+		}
+	}
+
+	// insertions at the same position stay in the order of their rules,
+	// ahead of a replacement starting there
+	slices.SortStableFunc(ret, func(a, b rules.TextEdit) int {
+		return cmp.Or(comparePositions(a.Range.Start, b.Range.Start), comparePositions(a.Range.End, b.Range.End))
+	})
+	return
+}
+
+// ApplyEdits applies the edits returned by Fix to the content of the file
+// they are for
+func ApplyEdits(content string, edits []rules.TextEdit) (string, error) {
+	// lineStarts holds the offset of the first character of every line
+	lineStarts := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offset := func(pos parser.Position) (int, error) {
+		if pos.Line < 1 || pos.Line > len(lineStarts) {
+			return 0, fmt.Errorf("line %d is out of range", pos.Line)
+		}
+		ret := lineStarts[pos.Line-1] + pos.Column - 1
+		if pos.Column < 1 || ret > len(content) {
+			return 0, fmt.Errorf("column %d of line %d is out of range", pos.Column, pos.Line)
+		}
+		return ret, nil
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, edit := range edits {
+		start, err := offset(edit.Range.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := offset(edit.Range.End)
+		if err != nil {
+			return "", err
+		}
+		if start < last || end < start {
+			return "", fmt.Errorf("edit at line %d overlaps another one", edit.Range.Start.Line)
+		}
+		b.WriteString(content[last:start])
+		b.WriteString(edit.NewText)
+		last = end
+	}
+	b.WriteString(content[last:])
+	return b.String(), nil
+}
+
+// overlaps reports whether two edits touch the same text. Insertions
+// overlap replacements around them, but not other insertions at the same
+// position or replacements starting or ending there.
+func overlaps(a, b rules.TextEdit) bool {
+	return comparePositions(a.Range.Start, b.Range.End) < 0 && comparePositions(b.Range.Start, a.Range.End) < 0
+}
+
+// comparePositions orders positions by line and column
+func comparePositions(a, b parser.Position) int {
+	return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+}
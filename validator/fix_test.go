@@ -0,0 +1,96 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/checkmake/checkmake/config"
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFix(t *testing.T) {
+	content := `.PHONY: clean
+all: build
+build:
+	cc main.c
+NOW = $(shell date)
+clean:
+	rm -f a.out
+test:
+	./a.out
+`
+	makefile, err := parser.ParseReader("Makefile", strings.NewReader(content))
+	require.NoError(t, err)
+	violations := Validate(makefile, &config.Config{})
+
+	// phonydeclared and minphony both ask for "all" to be declared phony
+	edits := Fix(makefile, &config.Config{}, violations)
+	fixed, err := ApplyEdits(content, edits)
+	require.NoError(t, err)
+	assert.Equal(t, `.PHONY: clean all test
+all: build
+build:
+	cc main.c
+NOW := $(shell date)
+clean:
+	rm -f a.out
+test:
+	./a.out
+`, fixed)
+
+	makefile, err = parser.ParseReader("Makefile", strings.NewReader(fixed))
+	require.NoError(t, err)
+	assert.Empty(t, Validate(makefile, &config.Config{}))
+}
+
+func TestFixSkipsIncludedFiles(t *testing.T) {
+	makefile := parser.Makefile{FileName: "Makefile"}
+	edits := Fix(makefile, &config.Config{}, rules.RuleViolationList{
+		{Rule: "phonydeclared", FileName: "common.mk", LineNumber: 1},
+	})
+	assert.Empty(t, edits)
+}
+
+func TestApplyEdits(t *testing.T) {
+	at := func(line, column int) parser.Position {
+		return parser.Position{Line: line, Column: column}
+	}
+	edit := func(start, end parser.Position, text string) rules.TextEdit {
+		return rules.TextEdit{Range: parser.Range{Start: start, End: end}, NewText: text}
+	}
+
+	ret, err := ApplyEdits("one\ntwo\n", []rules.TextEdit{
+		edit(at(1, 1), at(1, 1), "zero\n"),
+		edit(at(2, 1), at(2, 4), "2"),
+		edit(at(3, 1), at(3, 1), "three\n"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "zero\none\n2\nthree\n", ret)
+
+	_, err = ApplyEdits("one\n", []rules.TextEdit{edit(at(3, 1), at(3, 1), "x")})
+	assert.EqualError(t, err, "line 3 is out of range")
+
+	_, err = ApplyEdits("one\n", []rules.TextEdit{
+		edit(at(1, 1), at(1, 4), "1"),
+		edit(at(1, 2), at(1, 2), "x"),
+	})
+	assert.EqualError(t, err, "edit at line 1 overlaps another one")
+}
+
+func TestOverlaps(t *testing.T) {
+	edit := func(startColumn, endColumn int) rules.TextEdit {
+		return rules.TextEdit{Range: parser.Range{
+			Start: parser.Position{Line: 1, Column: startColumn},
+			End:   parser.Position{Line: 1, Column: endColumn},
+		}}
+	}
+
+	assert.True(t, overlaps(edit(1, 5), edit(4, 8)))
+	assert.True(t, overlaps(edit(1, 5), edit(3, 3)), "insertion within a replacement")
+	assert.False(t, overlaps(edit(1, 5), edit(5, 8)))
+	assert.False(t, overlaps(edit(1, 5), edit(5, 5)), "insertion right after a replacement")
+	assert.False(t, overlaps(edit(3, 3), edit(3, 3)), "insertions at the same position")
+}
@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+)
+
+// UnusedSuppression is the name violations of suppression comments which
+// don't silence anything are reported under. Like a rule it can be turned
+// off with "disabled = true" in its configuration section.
+const UnusedSuppression = "unusedsuppression"
+
+// suppressionPrefix starts every checkmake directive in a comment
+const suppressionPrefix = "checkmake:"
+
+// suppression silences violations of a single rule, or of all rules if the
+// rule is empty, within a range of lines of a file
+type suppression struct {
+	comment  parser.Comment
+	fileName string
+	rule     string
+	// first and last are the lines the suppression covers, for file wide
+	// suppressions they are 0 and math.MaxInt
+	first, last int
+	used        bool
+}
+
+// covers reports whether the suppression silences the violation
+func (s *suppression) covers(violation rules.RuleViolation) bool {
+	return (s.rule == "" || s.rule == violation.Rule) &&
+		s.fileName == violation.FileName &&
+		s.first <= violation.LineNumber && violation.LineNumber <= s.last
+}
+
+// parseSuppressions collects the suppressions from the checkmake directives
+// in the comments of the Makefile:
+//
+//	# checkmake:disable-next-line=rule,...
+//	# checkmake:disable=rule,...
+//	# checkmake:enable=rule,...
+//	# checkmake:disable-file=rule,...
+//
+// Without a list of rules a directive applies to all of them. A disable
+// lasts up to the enable of the same rule, or the end of the file. Text
+// after the directive, separated by whitespace, is ignored.
+func parseSuppressions(makefile parser.Makefile) (ret []*suppression) {
+	// open holds the suppressions which are waiting for an enable
+	var open []*suppression
+
+	for _, comment := range makefile.Comments {
+		fields := strings.Fields(comment.Text)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], suppressionPrefix) {
+			continue
+		}
+		directive, list, _ := strings.Cut(strings.TrimPrefix(fields[0], suppressionPrefix), "=")
+		names := []string{""}
+		if list != "" {
+			names = strings.Split(list, ",")
+		}
+		fileName := rules.FileNameFor(makefile, comment.FileName)
+		line := comment.Range.End.Line
+
+		for _, name := range names {
+			s := &suppression{comment: comment, fileName: fileName, rule: name}
+			switch directive {
+			case "disable-next-line":
+				s.first, s.last = line+1, line+1
+			case "disable":
+				s.first, s.last = line, math.MaxInt
+				open = append(open, s)
+			case "disable-file":
+				s.first, s.last = 0, math.MaxInt
+			case "enable":
+				for _, o := range open {
+					if o.fileName == fileName && o.last == math.MaxInt && (name == "" || o.rule == name) {
+						o.last = line
+					}
+				}
+				continue
+			default:
+				continue
+			}
+			ret = append(ret, s)
+		}
+	}
+	return
+}
+
+// suppress removes the violations silenced by a suppression from the list
+// and reports the suppressions of the rules that ran which didn't silence
+// anything, if UnusedSuppression is among the rules that ran
+func suppress(violations rules.RuleViolationList, suppressions []*suppression, ran map[string]bool) (ret rules.RuleViolationList) {
+	for _, violation := range violations {
+		suppressed := false
+		for _, s := range suppressions {
+			if s.covers(violation) {
+				s.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			ret = append(ret, violation)
+		}
+	}
+
+	if !ran[UnusedSuppression] {
+		return
+	}
+	registered := rules.GetRegisteredRules()
+	for _, s := range suppressions {
+		known := s.rule == "" || s.rule == UnusedSuppression || registered[s.rule] != nil
+		if s.used || s.rule == UnusedSuppression || (known && !ran[s.rule] && s.rule != "") {
+			continue
+		}
+		message := "Suppression of all rules is unused."
+		if !known {
+			message = fmt.Sprintf("Suppression of unknown rule %q.", s.rule)
+		} else if s.rule != "" {
+			message = fmt.Sprintf("Suppression of rule %q is unused.", s.rule)
+		}
+		violation := rules.RuleViolation{
+			Rule:       UnusedSuppression,
+			Violation:  message,
+			FileName:   s.fileName,
+			LineNumber: s.comment.LineNumber,
+			Range:      s.comment.Range,
+		}
+		// unused suppressions can be suppressed themselves, but not by
+		// suppressions of all rules
+		if !slices.ContainsFunc(suppressions, func(other *suppression) bool {
+			return other.rule == UnusedSuppression && other.covers(violation)
+		}) {
+			ret = append(ret, violation)
+		}
+	}
+	return
+}
@@ -0,0 +1,118 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/checkmake/checkmake/config"
+	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lines returns the line numbers of the violations of the rule
+func lines(violations rules.RuleViolationList, name string) (ret []int) {
+	for _, violation := range violations {
+		if violation.Rule == name {
+			ret = append(ret, violation.LineNumber)
+		}
+	}
+	return
+}
+
+// validateString parses and validates the Makefile
+func validateString(t *testing.T, makefile string, cfg *config.Config) rules.RuleViolationList {
+	t.Helper()
+	parsed, err := parser.ParseReader("Makefile", strings.NewReader(makefile))
+	require.NoError(t, err)
+	return Validate(parsed, cfg)
+}
+
+func TestSuppress_DisableNextLine(t *testing.T) {
+	violations := validateString(t, `# checkmake:disable-next-line=phonydeclared
+one:
+two:
+# checkmake:disable-next-line
+three:
+`, &config.Config{})
+
+	assert.Equal(t, []int{3}, lines(violations, "phonydeclared"))
+	assert.Empty(t, lines(violations, UnusedSuppression))
+}
+
+func TestSuppress_DisableEnable(t *testing.T) {
+	violations := validateString(t, `one:
+# checkmake:disable=phonydeclared,uniquetargets
+two:
+two:
+# checkmake:enable=phonydeclared
+three:
+three:
+# checkmake:enable
+four:
+four:
+`, &config.Config{})
+
+	assert.Equal(t, []int{1, 6, 7, 9, 10}, lines(violations, "phonydeclared"))
+	assert.Equal(t, []int{10}, lines(violations, "uniquetargets"))
+	assert.Empty(t, lines(violations, UnusedSuppression))
+}
+
+func TestSuppress_DisableFile(t *testing.T) {
+	violations := validateString(t, `one:
+two:
+# checkmake:disable-file=phonydeclared,minphony
+`, &config.Config{})
+
+	assert.Empty(t, lines(violations, "phonydeclared"))
+	assert.Empty(t, lines(violations, "minphony"))
+	assert.Empty(t, lines(violations, UnusedSuppression))
+}
+
+func TestSuppress_ReportsUnusedSuppressions(t *testing.T) {
+	violations := validateString(t, `# checkmake:disable-next-line=phonydeclared
+.PHONY: all
+all:
+# checkmake:disable=nosuchrule
+# checkmake:disable-next-line
+VERSION = 1
+# checkmake:disable-next-line=uniquetargets trailing text is ignored
+clean:
+# checkmake:disable-next-line=unusedsuppression
+# checkmake:disable-next-line=maxbodylength
+`, &config.Config{})
+
+	var unused []string
+	for _, violation := range violations {
+		if violation.Rule == UnusedSuppression {
+			unused = append(unused, violation.Violation)
+			assert.Equal(t, "Makefile", violation.FileName)
+		}
+	}
+	assert.Equal(t, []string{
+		`Suppression of rule "phonydeclared" is unused.`,
+		`Suppression of unknown rule "nosuchrule".`,
+		`Suppression of all rules is unused.`,
+		`Suppression of rule "uniquetargets" is unused.`,
+	}, unused)
+	assert.Equal(t, []int{8}, lines(violations, "phonydeclared"))
+}
+
+func TestSuppress_DisabledRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkmake.ini")
+	require.NoError(t, os.WriteFile(path, []byte("[phonydeclared]\ndisabled = true\n\n[unusedsuppression]\ndisabled = true\n"), 0o644))
+	cfg, err := config.NewConfigFromFile(path)
+	require.NoError(t, err)
+
+	violations := validateString(t, `# checkmake:disable-next-line=phonydeclared
+all:
+# checkmake:disable-next-line=uniquetargets
+clean:
+`, cfg)
+
+	assert.Empty(t, lines(violations, "phonydeclared"))
+	assert.Empty(t, lines(violations, UnusedSuppression))
+}
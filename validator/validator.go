@@ -12,24 +12,66 @@ import (
 
 	// rules register themselves via their package's init function, so we can
 	// just blank import it
+	_ "github.com/checkmake/checkmake/rules/gnuextensions"
 	_ "github.com/checkmake/checkmake/rules/maxbodylength"
 	_ "github.com/checkmake/checkmake/rules/minphony"
+	_ "github.com/checkmake/checkmake/rules/missinginclude"
 	_ "github.com/checkmake/checkmake/rules/phonydeclared"
 	_ "github.com/checkmake/checkmake/rules/timestampexpanded"
 	_ "github.com/checkmake/checkmake/rules/uniquetargets"
+	_ "github.com/checkmake/checkmake/rules/unrecognizedline"
 )
 
-// Validate let's you validate a passed in Makefile with the provided config
+// Validate let's you validate a passed in Makefile with the provided config.
+// Violations silenced by suppression comments in the Makefile are left out,
+// suppressions which don't silence anything are reported instead. The
+// violations are sorted by file, line, column and rule.
 func Validate(makefile parser.Makefile, cfg *config.Config) (ret rules.RuleViolationList) {
-	rules := rules.GetRegisteredRules()
+	ran := map[string]bool{}
 
-	for name, rule := range rules {
+	for _, rule := range rules.GetRulesSorted() {
+		name := rule.Name()
 		logger.Debug(fmt.Sprintf("Running rule '%s'...", name))
 		ruleConfig := cfg.GetRuleConfig(name)
 		if ruleConfig["disabled"] != "true" {
-			ret = append(ret, rule.Run(makefile, ruleConfig)...)
+			severity := severityFor(name, ruleConfig, rules.DefaultSeverity(rule))
+			for _, violation := range rule.Run(makefile, ruleConfig) {
+				violation.Severity = severity
+				ret = append(ret, violation)
+			}
+			ran[name] = true
 		}
 	}
 
+	// unused suppressions are reported like violations of a rule, which
+	// can be disabled and configured as well
+	unusedConfig := cfg.GetRuleConfig(UnusedSuppression)
+	if unusedConfig["disabled"] != "true" {
+		ran[UnusedSuppression] = true
+	}
+	ret = suppress(ret, parseSuppressions(makefile), ran)
+	unusedSeverity := severityFor(UnusedSuppression, unusedConfig, rules.SeverityWarning)
+	for i := range ret {
+		if ret[i].Rule == UnusedSuppression {
+			ret[i].Severity = unusedSeverity
+		}
+	}
+	ret.Sort(rules.SortByFile)
+
 	return
 }
+
+// severityFor returns the severity configured for the rule, or the given
+// default if there is none or it is invalid
+func severityFor(name string, cfg rules.RuleConfig, fallback rules.Severity) rules.Severity {
+	value, ok := cfg["severity"]
+	if !ok {
+		return fallback
+	}
+	severity, err := rules.ParseSeverity(value)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Rule '%s': %s, using %s", name, err.Error(), fallback))
+		return fallback
+	}
+	return severity
+}
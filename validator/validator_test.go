@@ -1,14 +1,47 @@
 package validator
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/checkmake/checkmake/config"
 	"github.com/checkmake/checkmake/parser"
+	"github.com/checkmake/checkmake/rules"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidator(t *testing.T) {
 	violations := Validate(parser.Makefile{}, &config.Config{})
 	assert.Equal(t, 3, len(violations))
 }
+
+func TestValidatorSeverities(t *testing.T) {
+	violations := Validate(parser.Makefile{}, &config.Config{})
+	for _, violation := range violations {
+		assert.Equal(t, rules.SeverityWarning, violation.Severity, violation.Rule)
+	}
+}
+
+func TestValidatorSortsViolations(t *testing.T) {
+	makefile, err := parser.ParseReader("Makefile", strings.NewReader("NOW = $(shell date)\nall:\nall:\n  oops\n"))
+	require.NoError(t, err)
+
+	for range 10 {
+		var got []string
+		for _, violation := range Validate(makefile, &config.Config{}) {
+			got = append(got, fmt.Sprintf("%d:%s:%s", violation.LineNumber, violation.Rule, violation.Violation))
+		}
+		assert.Equal(t, []string{
+			`1:timestampexpanded:Variable "NOW" possibly contains a timestamp and should be simply expanded.`,
+			`2:phonydeclared:Target "all" should be declared PHONY.`,
+			`3:minphony:Required target "all" must be declared PHONY.`,
+			`3:minphony:Required target "clean" is missing from the Makefile.`,
+			`3:minphony:Required target "test" is missing from the Makefile.`,
+			`3:phonydeclared:Target "all" should be declared PHONY.`,
+			`3:uniquetargets:Target "all" defined multiple times (lines 2 and 3).`,
+			`4:unrecognizedline:Line "oops" could not be parsed: recipe line indented with spaces.`,
+		}, got)
+	}
+}